@@ -0,0 +1,60 @@
+package moneybutton
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// readJSONFile will decode the JSON file into v (a missing or empty file is not an error)
+func readJSONFile(filename string, v interface{}) error {
+	data, err := ioutil.ReadFile(filename) //nolint:gosec // filename is supplied by the caller
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(data) == 0) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSONFileAtomic will encode v and atomically replace the file (write to a temp file, sync, rename)
+//
+// The file is only readable by the current user (0600)
+func writeJSONFileAtomic(filename string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Create the temp file in the same directory (rename is only atomic on the same filesystem)
+	var tmp *os.File
+	if tmp, err = ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*"); err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmpName)
+		}
+	}()
+
+	if err = tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	err = os.Rename(tmpName, filename)
+	return err
+}
@@ -0,0 +1,87 @@
+package moneybutton
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Scopes is a list of MoneyButton oAuth permissions (IE: PermissionsIdentity)
+type Scopes []string
+
+// String will return the scopes as a space delimited list (oAuth format)
+func (s Scopes) String() string {
+	return strings.Join(s, " ")
+}
+
+// GetAuthorizationURL will return the URL for sending a user to the MoneyButton consent screen
+//
+// The state value is returned on the redirect and should be verified to prevent CSRF.
+//
+// Specs: https://docs.moneybutton.com/docs/api-oauth-endpoints.html#authorization-endpoint
+func (c *Client) GetAuthorizationURL(clientID, redirectURI string, scopes Scopes,
+	state string) (string, error) {
+
+	// Validate and build the query
+	query, err := authorizationQuery(clientID, redirectURI, scopes, state)
+	if err != nil {
+		return "", err
+	}
+
+	return c.oauthURL(endpointAuthorize) + "?" + query.Encode(), nil
+}
+
+// GetAuthorizationURLWithPKCE will return the URL for sending a user to the MoneyButton consent screen
+// including the PKCE code challenge (see: NewPKCE())
+// Use GetRefreshTokenWithPKCE() with the same PKCE code verifier to exchange the auth code
+//
+// Specs: https://datatracker.ietf.org/doc/html/rfc7636#section-4.3
+func (c *Client) GetAuthorizationURLWithPKCE(clientID, redirectURI string, scopes Scopes,
+	state string, pkce *PKCE) (string, error) {
+
+	// Check the PKCE values
+	if pkce == nil || len(pkce.CodeChallenge) == 0 {
+		return "", fmt.Errorf("missing required parameter: %s", "pkce")
+	} else if pkce.CodeChallengeMethod != pkceChallengeMethodS256 {
+		return "", fmt.Errorf("unsupported code challenge method: %s", pkce.CodeChallengeMethod)
+	}
+
+	// Validate and build the query
+	query, err := authorizationQuery(clientID, redirectURI, scopes, state)
+	if err != nil {
+		return "", err
+	}
+	query.Set("code_challenge", pkce.CodeChallenge)
+	query.Set("code_challenge_method", pkce.CodeChallengeMethod)
+
+	return c.oauthURL(endpointAuthorize) + "?" + query.Encode(), nil
+}
+
+// authorizationQuery will validate and build the query for the authorization endpoint
+func authorizationQuery(clientID, redirectURI string, scopes Scopes, state string) (url.Values, error) {
+
+	// Check required parameters
+	if len(clientID) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "clientID")
+	} else if len(redirectURI) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "redirectURI")
+	} else if len(scopes) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "scopes")
+	}
+
+	// Make sure the redirect is a valid absolute URL
+	if u, err := url.Parse(redirectURI); err != nil || !u.IsAbs() {
+		return nil, fmt.Errorf("invalid parameter: %s", "redirectURI")
+	}
+
+	// Build the query (escaping is handled by url.Values)
+	query := url.Values{}
+	query.Set("client_id", clientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("response_type", responseTypeCode)
+	query.Set("scope", scopes.String())
+	if len(state) > 0 {
+		query.Set("state", state)
+	}
+	return query, nil
+}
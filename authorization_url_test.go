@@ -0,0 +1,148 @@
+package moneybutton
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScopes_String tests the method String()
+func TestScopes_String(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no scopes", func(t *testing.T) {
+		assert.Equal(t, "", Scopes{}.String())
+	})
+
+	t.Run("multiple scopes", func(t *testing.T) {
+		assert.Equal(
+			t,
+			PermissionsIdentity+" "+PermissionsProfile+" "+PermissionsBalance,
+			Scopes{PermissionsIdentity, PermissionsProfile, PermissionsBalance}.String(),
+		)
+	})
+}
+
+// TestClient_GetAuthorizationURL tests the method GetAuthorizationURL()
+func TestClient_GetAuthorizationURL(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(nil, nil, nil)
+	scopes := Scopes{PermissionsIdentity, PermissionsProfile}
+
+	t.Run("missing client id", func(t *testing.T) {
+		authURL, err := client.GetAuthorizationURL("", "http://domain.com", scopes, "state")
+		assert.Error(t, err)
+		assert.Equal(t, "", authURL)
+	})
+
+	t.Run("missing redirect uri", func(t *testing.T) {
+		authURL, err := client.GetAuthorizationURL("1234567", "", scopes, "state")
+		assert.Error(t, err)
+		assert.Equal(t, "", authURL)
+	})
+
+	t.Run("missing scopes", func(t *testing.T) {
+		authURL, err := client.GetAuthorizationURL("1234567", "http://domain.com", nil, "state")
+		assert.Error(t, err)
+		assert.Equal(t, "", authURL)
+	})
+
+	t.Run("relative redirect uri", func(t *testing.T) {
+		authURL, err := client.GetAuthorizationURL("1234567", "/callback", scopes, "state")
+		assert.Error(t, err)
+		assert.Equal(t, "", authURL)
+	})
+
+	t.Run("valid url", func(t *testing.T) {
+		authURL, err := client.GetAuthorizationURL(
+			"1234567", "http://domain.com/callback?source=app&x=a b", scopes, "st@te&=1",
+		)
+		require.NoError(t, err)
+
+		u, err := url.Parse(authURL)
+		require.NoError(t, err)
+		assert.Equal(t, OauthURL+endpointAuthorize, u.Scheme+"://"+u.Host+u.Path)
+
+		query := u.Query()
+		assert.Equal(t, "1234567", query.Get("client_id"))
+		assert.Equal(t, "http://domain.com/callback?source=app&x=a b", query.Get("redirect_uri"))
+		assert.Equal(t, responseTypeCode, query.Get("response_type"))
+		assert.Equal(t, PermissionsIdentity+" "+PermissionsProfile, query.Get("scope"))
+		assert.Equal(t, "st@te&=1", query.Get("state"))
+	})
+
+	t.Run("no state", func(t *testing.T) {
+		authURL, err := client.GetAuthorizationURL("1234567", "http://domain.com", scopes, "")
+		require.NoError(t, err)
+
+		u, err := url.Parse(authURL)
+		require.NoError(t, err)
+		_, ok := u.Query()["state"]
+		assert.False(t, ok)
+	})
+}
+
+// TestClient_GetAuthorizationURLWithPKCE tests the method GetAuthorizationURLWithPKCE()
+func TestClient_GetAuthorizationURLWithPKCE(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(nil, nil, nil)
+	scopes := Scopes{PermissionsIdentity}
+
+	t.Run("missing pkce", func(t *testing.T) {
+		authURL, err := client.GetAuthorizationURLWithPKCE("1234567", "http://domain.com", scopes, "state", nil)
+		assert.Error(t, err)
+		assert.Equal(t, "", authURL)
+	})
+
+	t.Run("unsupported challenge method", func(t *testing.T) {
+		authURL, err := client.GetAuthorizationURLWithPKCE(
+			"1234567", "http://domain.com", scopes, "state",
+			&PKCE{CodeChallenge: rfcCodeVerifier, CodeChallengeMethod: "plain"},
+		)
+		assert.Error(t, err)
+		assert.Equal(t, "", authURL)
+	})
+
+	t.Run("missing client id", func(t *testing.T) {
+		pkce, err := NewPKCEFromVerifier(rfcCodeVerifier)
+		require.NoError(t, err)
+		authURL, err := client.GetAuthorizationURLWithPKCE("", "http://domain.com", scopes, "state", pkce)
+		assert.Error(t, err)
+		assert.Equal(t, "", authURL)
+	})
+
+	t.Run("valid url", func(t *testing.T) {
+		pkce, err := NewPKCEFromVerifier(rfcCodeVerifier)
+		require.NoError(t, err)
+		authURL, err := client.GetAuthorizationURLWithPKCE("1234567", "http://domain.com", scopes, "state", pkce)
+		require.NoError(t, err)
+
+		u, err := url.Parse(authURL)
+		require.NoError(t, err)
+		query := u.Query()
+		assert.Equal(t, "1234567", query.Get("client_id"))
+		assert.Equal(t, "state", query.Get("state"))
+		assert.Equal(t, rfcCodeChallenge, query.Get("code_challenge"))
+		assert.Equal(t, pkceChallengeMethodS256, query.Get("code_challenge_method"))
+		assert.Empty(t, query.Get("code_verifier"))
+	})
+}
+
+// ExampleClient_GetAuthorizationURL example using GetAuthorizationURL()
+func ExampleClient_GetAuthorizationURL() {
+	client := NewClient(nil, nil, nil)
+
+	authURL, _ := client.GetAuthorizationURL(
+		"your-client-id",
+		"https://domain.com/callback",
+		Scopes{PermissionsIdentity, PermissionsProfile},
+		"random-state",
+	)
+	fmt.Println(authURL)
+	// Output:https://www.moneybutton.com/oauth/v1/authorize?client_id=your-client-id&redirect_uri=https%3A%2F%2Fdomain.com%2Fcallback&response_type=code&scope=auth.user_identity%3Aread+users.profiles%3Aread&state=random-state
+}
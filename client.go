@@ -1,40 +1,64 @@
 package moneybutton
 
 import (
+	"log/slog"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gojektech/heimdall/v6"
 	"github.com/gojektech/heimdall/v6/httpclient"
 )
 
-// httpInterface is used for the http client (mocking heimdall)
-type httpInterface interface {
+// HTTPInterface is used for the http client (mocking heimdall, recording or replaying requests)
+type HTTPInterface interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-// Client is the parent struct that contains the miner clients and list of miners to use
+// Client is the parent struct that contains the HTTP client, options and environment
 type Client struct {
-	httpClient httpInterface  // Interface for all HTTP requests
-	Options    *ClientOptions // Client options config
+	Environment *Environment   // Environment (API and oAuth URLs)
+	httpClient  HTTPInterface  // Interface for all HTTP requests
+	Options     *ClientOptions // Client options config
 }
 
 // ClientOptions holds all the configuration for connection, dialer and transport
+//
+// The hooks and middleware run on every request in this order: BeforeRequest hooks (in order),
+// Middleware (the first one is the outermost), the HTTP client, then AfterResponse hooks (in order)
+//
+// Tokens, auth codes and client secrets are redacted in RequestResponse, the AfterResponse hooks and
+// error messages, set DisableRedaction to keep the raw values (for local debugging only)
+//
+// Set a Logger to log every request: success at debug level, client errors (4xx) at warn level and
+// server or transport errors at error level (the client does not log without a Logger)
+//
+// Set Metrics to count the requests, errors, latency and token refreshes (IE: NewPrometheusMetrics())
+//
+// Set a Tracer to start a span for every API call, the W3C traceparent header is sent for every context
+// with a TraceParent (see: ContextWithTraceParent()) with or without a Tracer
 type ClientOptions struct {
-	BackOffExponentFactor          float64       `json:"back_off_exponent_factor"`
-	BackOffInitialTimeout          time.Duration `json:"back_off_initial_timeout"`
-	BackOffMaximumJitterInterval   time.Duration `json:"back_off_maximum_jitter_interval"`
-	BackOffMaxTimeout              time.Duration `json:"back_off_max_timeout"`
-	DialerKeepAlive                time.Duration `json:"dialer_keep_alive"`
-	DialerTimeout                  time.Duration `json:"dialer_timeout"`
-	RequestRetryCount              int           `json:"request_retry_count"`
-	RequestTimeout                 time.Duration `json:"request_timeout"`
-	TransportExpectContinueTimeout time.Duration `json:"transport_expect_continue_timeout"`
-	TransportIdleTimeout           time.Duration `json:"transport_idle_timeout"`
-	TransportMaxIdleConnections    int           `json:"transport_max_idle_connections"`
-	TransportTLSHandshakeTimeout   time.Duration `json:"transport_tls_handshake_timeout"`
-	UserAgent                      string        `json:"user_agent"`
+	AfterResponse                  []AfterResponseHook `json:"-"`
+	BackOffExponentFactor          float64             `json:"back_off_exponent_factor"`
+	BackOffInitialTimeout          time.Duration       `json:"back_off_initial_timeout"`
+	BackOffMaximumJitterInterval   time.Duration       `json:"back_off_maximum_jitter_interval"`
+	BackOffMaxTimeout              time.Duration       `json:"back_off_max_timeout"`
+	BeforeRequest                  []BeforeRequestHook `json:"-"`
+	DialerKeepAlive                time.Duration       `json:"dialer_keep_alive"`
+	DialerTimeout                  time.Duration       `json:"dialer_timeout"`
+	DisableRedaction               bool                `json:"disable_redaction"`
+	Logger                         *slog.Logger        `json:"-"`
+	Metrics                        Metrics             `json:"-"`
+	Middleware                     []Middleware        `json:"-"`
+	RequestRetryCount              int                 `json:"request_retry_count"`
+	RequestTimeout                 time.Duration       `json:"request_timeout"`
+	Tracer                         Tracer              `json:"-"`
+	TransportExpectContinueTimeout time.Duration       `json:"transport_expect_continue_timeout"`
+	TransportIdleTimeout           time.Duration       `json:"transport_idle_timeout"`
+	TransportMaxIdleConnections    int                 `json:"transport_max_idle_connections"`
+	TransportTLSHandshakeTimeout   time.Duration       `json:"transport_tls_handshake_timeout"`
+	UserAgent                      string              `json:"user_agent"`
 }
 
 // DefaultClientOptions will return an clientOptions struct with the default settings.
@@ -57,16 +81,30 @@ func DefaultClientOptions() (clientOptions *ClientOptions) {
 	}
 }
 
-// Environment is the Handcash environment information
+// Environment is the MoneyButton environment information
+//
+// Use a custom environment for a staging server, a compatible service or a local fake
 type Environment struct {
-	APIURL      string `json:"api_url"`
-	ClientURL   string `json:"client_url"`
-	Environment string `json:"environment"`
+	APIURL      string `json:"api_url"`     // IE: https://www.moneybutton.com/api/v1/
+	ClientURL   string `json:"client_url"`  // IE: https://www.moneybutton.com/
+	Environment string `json:"environment"` // Name of the environment (IE: production)
+	OauthURL    string `json:"oauth_url"`   // IE: https://www.moneybutton.com/oauth/v1/
+}
+
+// DefaultEnvironment will return the production environment
+func DefaultEnvironment() *Environment {
+	return &Environment{
+		APIURL:      APIURL,
+		ClientURL:   ClientURL,
+		Environment: EnvironmentProduction,
+		OauthURL:    OauthURL,
+	}
 }
 
 // NewClient creates a new client for requests
 // If no environment is set, production is used as the default
-func NewClient(options *ClientOptions, customHTTPClient *http.Client) (c *Client) {
+func NewClient(options *ClientOptions, customHTTPClient *http.Client,
+	environment *Environment) (c *Client) {
 
 	// Create a client
 	c = new(Client)
@@ -76,6 +114,20 @@ func NewClient(options *ClientOptions, customHTTPClient *http.Client) (c *Client
 		options = DefaultClientOptions()
 	}
 
+	// Set the environment (either default or custom)
+	if environment == nil {
+		environment = DefaultEnvironment()
+	}
+	c.Environment = &Environment{
+		APIURL:      withTrailingSlash(environment.APIURL),
+		ClientURL:   withTrailingSlash(environment.ClientURL),
+		Environment: environment.Environment,
+		OauthURL:    withTrailingSlash(environment.OauthURL),
+	}
+
+	// Set the options
+	c.Options = options
+
 	// Is there a custom HTTP client to use?
 	if customHTTPClient != nil {
 		c.httpClient = customHTTPClient
@@ -95,25 +147,24 @@ func NewClient(options *ClientOptions, customHTTPClient *http.Client) (c *Client
 		TLSHandshakeTimeout:   options.TransportTLSHandshakeTimeout,
 	}
 
-	// Set the options
-	c.Options = options
-
 	// Determine the strategy for the http client
 	if options.RequestRetryCount <= 0 {
 
 		// no retry enabled
-		c.httpClient = httpclient.NewClient(
+		httpClient := httpclient.NewClient(
 			httpclient.WithHTTPTimeout(options.RequestTimeout),
 			httpclient.WithHTTPClient(&http.Client{
 				Transport: clientDefaultTransport,
 				Timeout:   options.RequestTimeout,
 			}),
 		)
+		httpClient.AddPlugin(attemptCounter{})
+		c.httpClient = httpClient
 		return
 	}
 
 	// Retry enabled - create exponential back-off
-	c.httpClient = httpclient.NewClient(
+	httpClient := httpclient.NewClient(
 		httpclient.WithHTTPTimeout(options.RequestTimeout),
 		httpclient.WithRetrier(heimdall.NewRetrier(
 			heimdall.NewExponentialBackoff(
@@ -128,6 +179,38 @@ func NewClient(options *ClientOptions, customHTTPClient *http.Client) (c *Client
 			Timeout:   options.RequestTimeout,
 		}),
 	)
+	httpClient.AddPlugin(attemptCounter{})
+	c.httpClient = httpClient
 
 	return
 }
+
+// HTTPClient will return the HTTP client used for all requests
+func (c *Client) HTTPClient() HTTPInterface {
+	return c.httpClient
+}
+
+// SetHTTPClient will replace the HTTP client used for all requests (the Middleware still applies)
+//
+// Use it to wrap the current client (IE: moneybuttontest.NewRecorder(filename, mode, c.HTTPClient()))
+func (c *Client) SetHTTPClient(httpClient HTTPInterface) {
+	c.httpClient = httpClient
+}
+
+// apiURL will return the full URL for an API endpoint
+func (c *Client) apiURL(endpoint string) string {
+	return c.Environment.APIURL + endpoint
+}
+
+// oauthURL will return the full URL for an oAuth endpoint
+func (c *Client) oauthURL(endpoint string) string {
+	return c.Environment.OauthURL + endpoint
+}
+
+// withTrailingSlash will make sure a (non-empty) base URL ends in a slash
+func withTrailingSlash(baseURL string) string {
+	if len(baseURL) > 0 && !strings.HasSuffix(baseURL, "/") {
+		return baseURL + "/"
+	}
+	return baseURL
+}
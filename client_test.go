@@ -1,17 +1,22 @@
 package moneybutton
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // newTestClient returns a client for mocking (using a custom HTTP interface)
-func newTestClient(httpClient httpInterface) *Client {
-	client := NewClient(nil, nil)
+func newTestClient(httpClient HTTPInterface) *Client {
+	client := NewClient(nil, nil, nil)
 	client.httpClient = httpClient
 	return client
 }
@@ -21,23 +26,121 @@ func TestNewClient(t *testing.T) {
 	t.Parallel()
 
 	t.Run("valid new client", func(t *testing.T) {
-		client := NewClient(nil, nil)
+		client := NewClient(nil, nil, nil)
 		assert.NotNil(t, client)
 		assert.NotNil(t, client.httpClient)
 		assert.NotNil(t, client.Options)
 	})
 
 	t.Run("custom http client", func(t *testing.T) {
-		client := NewClient(nil, http.DefaultClient)
+		client := NewClient(nil, http.DefaultClient, nil)
 		assert.NotNil(t, client)
 		assert.NotNil(t, client.httpClient)
-		assert.Nil(t, client.Options)
+		require.NotNil(t, client.Options)
+		assert.Equal(t, defaultUserAgent, client.Options.UserAgent)
 	})
+
+	t.Run("default environment", func(t *testing.T) {
+		client := NewClient(nil, nil, nil)
+		assert.NotNil(t, client)
+		assert.Equal(t, DefaultEnvironment(), client.Environment)
+	})
+
+	t.Run("custom environment", func(t *testing.T) {
+		client := NewClient(nil, nil, &Environment{
+			APIURL:      "http://localhost:3000/api/v1",
+			ClientURL:   "http://localhost:3000",
+			Environment: "local",
+			OauthURL:    "http://localhost:3000/oauth/v1/",
+		})
+		assert.NotNil(t, client)
+		assert.Equal(t, "http://localhost:3000/api/v1/", client.Environment.APIURL)
+		assert.Equal(t, "http://localhost:3000/", client.Environment.ClientURL)
+		assert.Equal(t, "local", client.Environment.Environment)
+		assert.Equal(t, "http://localhost:3000/oauth/v1/", client.Environment.OauthURL)
+	})
+}
+
+// mockHTTPEnvironment for mocking requests (records the requested URLs)
+type mockHTTPEnvironment struct {
+	urls []string
+}
+
+// Do is a mock http request
+func (m *mockHTTPEnvironment) Do(req *http.Request) (*http.Response, error) {
+	m.urls = append(m.urls, req.URL.String())
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`{"data":{"type":"resources","id":"1"}}`)),
+	}, nil
+}
+
+// TestClient_Environment tests that all endpoints use the client environment
+func TestClient_Environment(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockHTTPEnvironment{}
+	client := NewClient(nil, nil, &Environment{
+		APIURL:   "http://localhost:3000/api/v1/",
+		OauthURL: "http://localhost:3000/oauth/v1/",
+	})
+	client.httpClient = mock
+
+	ctx := context.Background()
+	_, err := client.GetRefreshToken(ctx, "1234567", "1234567", "http://domain.com")
+	require.NoError(t, err)
+	_, err = client.RefreshAccessToken(ctx, "1234567", "1234567")
+	require.NoError(t, err)
+	_, err = client.GetUserIdentity(ctx, "1234567")
+	require.NoError(t, err)
+	_, err = client.GetProfile(ctx, "12 3", "1234567")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"http://localhost:3000/oauth/v1/token",
+		"http://localhost:3000/oauth/v1/token",
+		"http://localhost:3000/api/v1/auth/user_identity",
+		"http://localhost:3000/api/v1/users/12%203/profile",
+	}, mock.urls)
+
+	authURL, err := client.GetAuthorizationURL("1234567", "http://domain.com", Scopes{PermissionsIdentity}, "")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(authURL, "http://localhost:3000/oauth/v1/authorize?"))
+}
+
+// mockHTTPWrapper for mocking a wrapped HTTP client (counts the requests)
+type mockHTTPWrapper struct {
+	next     HTTPInterface
+	requests int
+}
+
+// Do is a mock http request
+func (m *mockHTTPWrapper) Do(req *http.Request) (*http.Response, error) {
+	m.requests++
+	return m.next.Do(req)
+}
+
+// TestClient_SetHTTPClient tests the methods HTTPClient() and SetHTTPClient()
+func TestClient_SetHTTPClient(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockHTTPEnvironment{}
+	client := newTestClient(mock)
+	assert.Equal(t, mock, client.HTTPClient())
+
+	wrapper := &mockHTTPWrapper{next: client.HTTPClient()}
+	client.SetHTTPClient(wrapper)
+	assert.Equal(t, wrapper, client.HTTPClient())
+
+	_, err := client.GetUserIdentity(context.Background(), "1234567")
+	require.NoError(t, err)
+	assert.Equal(t, 1, wrapper.requests)
+	assert.Len(t, mock.urls, 1)
 }
 
 // ExampleNewClient example using NewClient()
 func ExampleNewClient() {
-	client := NewClient(nil, nil)
+	client := NewClient(nil, nil, nil)
 
 	fmt.Printf("created new client: %s", client.Options.UserAgent)
 	// Output:created new client: go-moneybutton: v0.2.0
@@ -46,7 +149,7 @@ func ExampleNewClient() {
 // BenchmarkNewClient benchmarks the method NewClient()
 func BenchmarkNewClient(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		_ = NewClient(nil, nil)
+		_ = NewClient(nil, nil, nil)
 	}
 }
 
@@ -75,7 +178,7 @@ func TestDefaultClientOptions(t *testing.T) {
 	t.Run("no retry", func(t *testing.T) {
 		options := DefaultClientOptions()
 		options.RequestRetryCount = 0
-		client := NewClient(options, nil)
+		client := NewClient(options, nil, nil)
 		assert.NotNil(t, client)
 		assert.NotNil(t, client.Options)
 	})
@@ -85,12 +188,24 @@ func TestDefaultClientOptions(t *testing.T) {
 func ExampleDefaultClientOptions() {
 	options := DefaultClientOptions()
 	options.UserAgent = "Custom UserAgent v1.0"
-	client := NewClient(options, nil)
+	client := NewClient(options, nil, nil)
 
 	fmt.Printf("created new client with user agent: %s", client.Options.UserAgent)
 	// Output:created new client with user agent: Custom UserAgent v1.0
 }
 
+// TestDefaultEnvironment tests the method DefaultEnvironment()
+func TestDefaultEnvironment(t *testing.T) {
+	t.Parallel()
+
+	environment := DefaultEnvironment()
+	assert.NotNil(t, environment)
+	assert.Equal(t, APIURL, environment.APIURL)
+	assert.Equal(t, ClientURL, environment.ClientURL)
+	assert.Equal(t, EnvironmentProduction, environment.Environment)
+	assert.Equal(t, OauthURL, environment.OauthURL)
+}
+
 // BenchmarkDefaultClientOptions benchmarks the method DefaultClientOptions()
 func BenchmarkDefaultClientOptions(b *testing.B) {
 	for i := 0; i < b.N; i++ {
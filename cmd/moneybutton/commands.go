@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tonicpow/go-moneybutton"
+)
+
+// Payment list settings
+const (
+	defaultPaymentsLimit = 20
+	maxPageSize          = 100
+	sortAsc              = "asc"
+	sortDesc             = "desc"
+)
+
+// tokenInfo is the output of the refresh command (the tokens are not printed)
+type tokenInfo struct {
+	Expiry    string `json:"expiry"`
+	Scope     string `json:"scope"`
+	TokenType string `json:"token_type"`
+}
+
+// runRefresh will refresh the access token (and save it)
+func runRefresh(ctx context.Context, a *app, args []string) error {
+	if err := parseFlags(a.newFlagSet("refresh"), args); err != nil {
+		return err
+	}
+	_, _, source, err := a.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	token, err := source.Refresh(ctx)
+	if err != nil {
+		return err
+	}
+	info := &tokenInfo{Expiry: formatTime(token.Expiry), Scope: token.Scope, TokenType: token.TokenType}
+	return a.print(info, fieldTable("Expiry", info.Expiry, "Scope", info.Scope))
+}
+
+// runWhoami will show the identity of the logged in user
+func runWhoami(ctx context.Context, a *app, args []string) error {
+	if err := parseFlags(a.newFlagSet("whoami"), args); err != nil {
+		return err
+	}
+	_, client, source, err := a.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	accessToken, err := source.AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	identity, err := client.GetUserIdentity(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+	user := identity.Identity()
+	if user == nil {
+		return fmt.Errorf("missing identity in the response")
+	}
+	return a.print(user, fieldTable("ID", user.ID, "Name", user.Name))
+}
+
+// runProfile will show the profile of the user (default is the logged in user)
+func runProfile(ctx context.Context, a *app, args []string) error {
+	flags := a.newFlagSet("profile")
+	userID := flags.String("user", "", "User ID (default is the logged in user)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	cfg, client, source, err := a.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	accessToken, err := source.AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	response, err := client.GetProfile(ctx, firstNonEmpty(*userID, cfg.UserID), accessToken)
+	if err != nil {
+		return err
+	}
+
+	profile := response.Profile()
+	if profile == nil {
+		return fmt.Errorf("missing profile in the response")
+	}
+	avatarURL := profile.RawAvatarURL
+	if profile.AvatarURL != nil {
+		avatarURL = profile.AvatarURL.String()
+	}
+	return a.print(profile, fieldTable(
+		"Name", profile.Name,
+		"Paymail", profile.PrimaryPaymail.String(),
+		"Bio", profile.Bio,
+		"Currency", profile.DefaultCurrency,
+		"Language", profile.DefaultLanguage,
+		"Avatar", avatarURL,
+		"Created", firstNonEmpty(formatTime(profile.CreatedAt), profile.RawCreatedAt),
+	))
+}
+
+// runBalance will show the balance of the user (default is the logged in user)
+func runBalance(ctx context.Context, a *app, args []string) error {
+	flags := a.newFlagSet("balance")
+	userID := flags.String("user", "", "User ID (default is the logged in user)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	cfg, client, source, err := a.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	accessToken, err := source.AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	balance, err := client.GetBalance(ctx, firstNonEmpty(*userID, cfg.UserID), accessToken)
+	if err != nil {
+		return err
+	}
+	attributes := balance.Balance()
+	if attributes == nil {
+		return fmt.Errorf("missing balance in the response")
+	}
+	return a.print(attributes, fieldTable(
+		"Amount", attributes.Amount.String()+" "+attributes.Currency,
+		"Satoshis", attributes.Satoshis.String(),
+	))
+}
+
+// runPayments will list the payments of the user (newest first by default)
+func runPayments(ctx context.Context, a *app, args []string) error {
+	flags := a.newFlagSet("payments")
+	buttonID := flags.String("button-id", "", "Only payments for the button ID")
+	limit := flags.Int("limit", defaultPaymentsLimit, "Maximum number of payments (0 is all)")
+	pageSize := flags.Int("page-size", 0, "Payments per request (default is the API default)")
+	sortOrder := flags.String("sort", sortDesc, "Sort by creation time: asc or desc")
+	status := flags.String("status", "", "Only payments with the status (IE: COMPLETED)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	options := &moneybutton.ListPaymentsOptions{
+		ButtonID: *buttonID,
+		PageSize: *pageSize,
+		Status:   moneybutton.PaymentStatus(strings.ToUpper(*status)),
+	}
+	switch *sortOrder {
+	case sortAsc:
+		options.Sort = moneybutton.SortCreatedAtAsc
+	case sortDesc:
+		options.Sort = moneybutton.SortCreatedAtDesc
+	default:
+		_, _ = fmt.Fprintf(a.stderr, "invalid sort: %s\n", *sortOrder)
+		flags.Usage()
+		return errUsage
+	}
+	if len(options.Status) > 0 && !options.Status.Valid() {
+		_, _ = fmt.Fprintf(a.stderr, "invalid status: %s\n", *status)
+		flags.Usage()
+		return errUsage
+	} else if *limit < 0 {
+		_, _ = fmt.Fprintf(a.stderr, "invalid limit: %d\n", *limit)
+		flags.Usage()
+		return errUsage
+	}
+
+	// Do not fetch more than needed
+	if options.PageSize == 0 && *limit > 0 && *limit < maxPageSize {
+		options.PageSize = *limit
+	}
+
+	_, client, source, err := a.session(ctx)
+	if err != nil {
+		return err
+	}
+	accessToken, err := source.AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	payments := make([]*moneybutton.PaymentData, 0)
+	it := client.PaymentIterator(accessToken, options)
+	for (*limit == 0 || len(payments) < *limit) && it.Next(ctx) {
+		payments = append(payments, it.Payment())
+	}
+	if err = it.Err(); err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"ID", "STATUS", "AMOUNT", "CURRENCY", "SATOSHIS", "CREATED"}}
+	for _, payment := range payments {
+		attributes := payment.Attributes
+		if attributes == nil {
+			attributes = &moneybutton.PaymentAttributes{}
+		}
+		t.rows = append(t.rows, []string{
+			payment.ID, attributes.Status.String(), attributes.Amount.String(), attributes.Currency,
+			attributes.Satoshis.String(), formatTime(attributes.CreatedAt),
+		})
+	}
+	return a.print(payments, t)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tonicpow/go-moneybutton"
+	"github.com/tonicpow/go-moneybutton/moneybuttontest"
+)
+
+// TestWhoami tests the whoami command
+func TestWhoami(t *testing.T) {
+	t.Parallel()
+
+	cli := newTestCLI(t)
+	cli.login(t)
+
+	require.Equal(t, 0, cli.run("whoami"), cli.stderr.String())
+	assert.Equal(t, "ID:    123\nName:  MrZ\n", cli.stdout.String())
+
+	require.Equal(t, 0, cli.run("-output", "json", "whoami"), cli.stderr.String())
+	var identity moneybutton.Identity
+	require.NoError(t, json.Unmarshal(cli.stdout.Bytes(), &identity))
+	assert.Equal(t, moneybutton.Identity{ID: "123", Name: "MrZ"}, identity)
+
+	require.Equal(t, 0, cli.run("-verbose", "whoami"), cli.stderr.String())
+	assert.Contains(t, cli.stderr.String(), `msg="moneybutton request" endpoint=GetUserIdentity`)
+	assert.Contains(t, cli.stderr.String(), "status=200")
+
+	// Empty identity
+	cli.server.RespondNext(moneybuttontest.EndpointUserIdentity, http.StatusOK, `{"data":{"type":"user_identities","id":"123"}}`)
+	assert.Equal(t, 1, cli.run("whoami"))
+	assert.Contains(t, cli.stderr.String(), "missing identity in the response")
+}
+
+// TestProfile tests the profile command
+func TestProfile(t *testing.T) {
+	t.Parallel()
+
+	cli := newTestCLI(t)
+	cli.login(t)
+
+	require.Equal(t, 0, cli.run("profile"), cli.stderr.String())
+	assert.Contains(t, cli.stdout.String(), "Paymail:   mrz@moneybutton.com\n")
+	assert.Contains(t, cli.stdout.String(), "Created:   2019-03-26T17:33:42Z\n")
+
+	require.Equal(t, 0, cli.run("-output", "json", "profile"), cli.stderr.String())
+	var profile moneybutton.Profile
+	require.NoError(t, json.Unmarshal(cli.stdout.Bytes(), &profile))
+	assert.Equal(t, "MrZ", profile.Name)
+	assert.Equal(t, moneybutton.Paymail("mrz@moneybutton.com"), profile.PrimaryPaymail)
+
+	cli.server.AddUser(&moneybuttontest.User{ID: "456", Name: "Other"})
+	require.Equal(t, 0, cli.run("profile", "-user", "456"), cli.stderr.String())
+	assert.Contains(t, cli.stdout.String(), "Name:      Other\n")
+}
+
+// TestBalance tests the balance command
+func TestBalance(t *testing.T) {
+	t.Parallel()
+
+	cli := newTestCLI(t)
+	cli.login(t)
+
+	require.Equal(t, 0, cli.run("balance"), cli.stderr.String())
+	assert.Equal(t, "Amount:    12.34 USD\nSatoshis:  5000000\n", cli.stdout.String())
+
+	require.Equal(t, 0, cli.run("-output", "json", "balance"), cli.stderr.String())
+	assert.JSONEq(t, `{"amount":12.34,"currency":"USD","satoshis":5000000}`, cli.stdout.String())
+}
+
+// TestPayments tests the payments command
+func TestPayments(t *testing.T) {
+	t.Parallel()
+
+	newPaymentsCLI := func(t *testing.T) *testCLI {
+		cli := newTestCLI(t)
+		start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := 1; i <= 30; i++ {
+			status := moneybutton.PaymentStatusCompleted
+			if i%3 == 0 {
+				status = moneybutton.PaymentStatusFailed
+			}
+			cli.server.AddPayment(&moneybuttontest.Payment{
+				Amount:    "0.01",
+				CreatedAt: start.Add(time.Duration(i) * time.Hour),
+				Currency:  "USD",
+				ID:        fmt.Sprintf("%d", i),
+				Satoshis:  "5000",
+				Status:    status,
+				UserID:    "123",
+			})
+		}
+		cli.login(t)
+		return cli
+	}
+
+	t.Run("table with the newest first", func(t *testing.T) {
+		cli := newPaymentsCLI(t)
+		require.Equal(t, 0, cli.run("payments", "-limit", "3"), cli.stderr.String())
+		lines := strings.Split(strings.TrimSpace(cli.stdout.String()), "\n")
+		require.Len(t, lines, 4)
+		assert.Equal(t, []string{"ID", "STATUS", "AMOUNT", "CURRENCY", "SATOSHIS", "CREATED"}, strings.Fields(lines[0]))
+		assert.Equal(t, []string{"30", "FAILED", "0.01", "USD", "5000", "2021-01-02T06:00:00Z"}, strings.Fields(lines[1]))
+		assert.Equal(t, 1, cli.server.Requests(moneybuttontest.EndpointPayments))
+	})
+
+	t.Run("all pages as json", func(t *testing.T) {
+		cli := newPaymentsCLI(t)
+		require.Equal(t, 0, cli.run(
+			"-output", "json", "payments", "-limit", "0", "-page-size", "7", "-sort", "asc", "-status", "completed",
+		), cli.stderr.String())
+
+		var payments []*moneybutton.PaymentData
+		require.NoError(t, json.Unmarshal(cli.stdout.Bytes(), &payments))
+		require.Len(t, payments, 20)
+		assert.Equal(t, "1", payments[0].ID)
+		for _, payment := range payments {
+			assert.Equal(t, moneybutton.PaymentStatusCompleted, payment.Attributes.Status)
+		}
+	})
+
+	t.Run("no payments", func(t *testing.T) {
+		cli := newTestCLI(t)
+		cli.login(t)
+		require.Equal(t, 0, cli.run("-output", "json", "payments"), cli.stderr.String())
+		assert.Equal(t, "[]\n", cli.stdout.String())
+	})
+
+	t.Run("invalid flags", func(t *testing.T) {
+		cli := newTestCLI(t)
+		assert.Equal(t, 2, cli.run("payments", "-sort", "random"))
+		assert.Contains(t, cli.stderr.String(), "invalid sort: random")
+		assert.Equal(t, 2, cli.run("payments", "-status", "unknown"))
+		assert.Contains(t, cli.stderr.String(), "invalid status: unknown")
+		assert.Equal(t, 2, cli.run("payments", "-limit", "-1"))
+		assert.Contains(t, cli.stderr.String(), "invalid limit: -1")
+	})
+}
+
+// TestRefresh tests the refresh command and the automatic refresh of expired tokens
+func TestRefresh(t *testing.T) {
+	t.Parallel()
+
+	t.Run("refresh saves the new token", func(t *testing.T) {
+		cli := newTestCLI(t)
+		cli.login(t)
+		a := &app{configDir: cli.configDir}
+		store, err := a.tokenStore()
+		require.NoError(t, err)
+		before, err := store.Get(context.Background(), "123")
+		require.NoError(t, err)
+
+		require.Equal(t, 0, cli.run("-output", "json", "refresh"), cli.stderr.String())
+		var info tokenInfo
+		require.NoError(t, json.Unmarshal(cli.stdout.Bytes(), &info))
+		assert.Equal(t, "Bearer", info.TokenType)
+		assert.NotEmpty(t, info.Expiry)
+		assert.NotContains(t, cli.stdout.String(), before.RefreshToken)
+
+		// The refresh token was rotated and saved
+		after, err := store.Get(context.Background(), "123")
+		require.NoError(t, err)
+		assert.NotEqual(t, before.RefreshToken, after.RefreshToken)
+		require.Equal(t, 0, cli.run("whoami"), cli.stderr.String())
+	})
+
+	t.Run("expired access token is refreshed", func(t *testing.T) {
+		cli := newTestCLI(t)
+		cli.login(t)
+		a := &app{configDir: cli.configDir}
+		store, err := a.tokenStore()
+		require.NoError(t, err)
+		token, err := store.Get(context.Background(), "123")
+		require.NoError(t, err)
+
+		// Expired on the server and locally
+		cli.server.ExpireAccessToken(token.AccessToken)
+		token.Expiry = time.Now().Add(-time.Minute)
+		require.NoError(t, store.Put(context.Background(), "123", token))
+
+		require.Equal(t, 0, cli.run("whoami"), cli.stderr.String())
+		refreshed, err := store.Get(context.Background(), "123")
+		require.NoError(t, err)
+		assert.NotEqual(t, token.AccessToken, refreshed.AccessToken)
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		cli := newTestCLI(t)
+		cli.login(t)
+		a := &app{configDir: cli.configDir}
+		store, err := a.tokenStore()
+		require.NoError(t, err)
+		token, err := store.Get(context.Background(), "123")
+		require.NoError(t, err)
+
+		cli.server.RevokeToken(token.AccessToken)
+		assert.Equal(t, 1, cli.run("refresh"))
+		assert.Contains(t, cli.stderr.String(), "refresh token is invalid")
+	})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tonicpow/go-moneybutton"
+)
+
+// Files in the config directory
+const (
+	configFile = "config.json"
+	tokensFile = "tokens.json"
+)
+
+// envConfigDir overrides the default config directory
+const envConfigDir = "MONEYBUTTON_CONFIG_DIR"
+
+// errNotLoggedIn is returned when there is no saved login
+var errNotLoggedIn = errors.New("not logged in (run: moneybutton login)")
+
+// config is the saved login (the tokens are saved separately in a moneybutton.FileTokenStore)
+type config struct {
+	ClientID    string                   `json:"client_id"`
+	Environment *moneybutton.Environment `json:"environment,omitempty"` // nil is production
+	UserID      string                   `json:"user_id"`
+}
+
+// defaultConfigDir will return the config directory (IE: ~/.config/moneybutton)
+func defaultConfigDir() string {
+	if dir := os.Getenv(envConfigDir); len(dir) > 0 {
+		return dir
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".moneybutton"
+	}
+	return filepath.Join(dir, "moneybutton")
+}
+
+// environmentFromBaseURL will return the environment for a MoneyButton compatible server
+// (IE: http://127.0.0.1:3000 for a local fake)
+func environmentFromBaseURL(baseURL string) *moneybutton.Environment {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return &moneybutton.Environment{
+		APIURL:      baseURL + "/api/v1/",
+		ClientURL:   baseURL + "/",
+		Environment: baseURL,
+		OauthURL:    baseURL + "/oauth/v1/",
+	}
+}
+
+// loadConfig will read the config file (a missing file is an empty config)
+func (a *app) loadConfig() (*config, error) {
+	cfg := new(config)
+	data, err := ioutil.ReadFile(filepath.Join(a.configDir, configFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return cfg, json.Unmarshal(data, cfg)
+}
+
+// saveConfig will write the config file (only readable by the current user)
+func (a *app) saveConfig(cfg *config) error {
+	if err := os.MkdirAll(a.configDir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(a.configDir, configFile), append(data, '\n'), 0o600)
+}
+
+// tokenStore will return the store of the saved tokens
+func (a *app) tokenStore() (*moneybutton.FileTokenStore, error) {
+	return moneybutton.NewFileTokenStore(filepath.Join(a.configDir, tokensFile))
+}
+
+// client will return a client for the environment of the config
+func (a *app) client(cfg *config) *moneybutton.Client {
+	options := moneybutton.DefaultClientOptions()
+	if a.verbose {
+		options.Logger = slog.New(slog.NewTextHandler(a.stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	return moneybutton.NewClient(options, nil, cfg.Environment)
+}
+
+// session will load the saved login and return a client with a token source
+// (refreshed tokens are saved automatically)
+func (a *app) session(ctx context.Context) (*config, *moneybutton.Client, *moneybutton.TokenSource, error) {
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return nil, nil, nil, err
+	} else if len(cfg.ClientID) == 0 || len(cfg.UserID) == 0 {
+		return nil, nil, nil, errNotLoggedIn
+	}
+
+	var store *moneybutton.FileTokenStore
+	if store, err = a.tokenStore(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	client := a.client(cfg)
+	source, err := client.NewStoredTokenSource(ctx, cfg.ClientID, cfg.UserID, store)
+	if errors.Is(err, moneybutton.ErrTokenNotFound) {
+		return nil, nil, nil, errNotLoggedIn
+	} else if err != nil {
+		return nil, nil, nil, err
+	}
+	return cfg, client, source, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tonicpow/go-moneybutton"
+)
+
+// TestConfig tests the methods loadConfig() and saveConfig()
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing file is an empty config", func(t *testing.T) {
+		a := &app{configDir: t.TempDir()}
+		cfg, err := a.loadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, &config{}, cfg)
+	})
+
+	t.Run("save and load", func(t *testing.T) {
+		a := &app{configDir: filepath.Join(t.TempDir(), "nested")}
+		cfg := &config{ClientID: "my-client-id", Environment: moneybutton.DefaultEnvironment(), UserID: "123"}
+		require.NoError(t, a.saveConfig(cfg))
+
+		loaded, err := a.loadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, cfg, loaded)
+
+		info, err := os.Stat(filepath.Join(a.configDir, configFile))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		a := &app{configDir: t.TempDir()}
+		require.NoError(t, ioutil.WriteFile(filepath.Join(a.configDir, configFile), []byte("{"), 0o600))
+		_, err := a.loadConfig()
+		assert.Error(t, err)
+	})
+}
+
+// TestDefaultConfigDir tests the method defaultConfigDir()
+func TestDefaultConfigDir(t *testing.T) {
+	t.Setenv(envConfigDir, "/tmp/moneybutton-test")
+	assert.Equal(t, "/tmp/moneybutton-test", defaultConfigDir())
+
+	t.Setenv(envConfigDir, "")
+	assert.Equal(t, "moneybutton", filepath.Base(defaultConfigDir()))
+}
+
+// TestEnvironmentFromBaseURL tests the method environmentFromBaseURL()
+func TestEnvironmentFromBaseURL(t *testing.T) {
+	t.Parallel()
+
+	env := environmentFromBaseURL("http://127.0.0.1:3000/")
+	assert.Equal(t, "http://127.0.0.1:3000/api/v1/", env.APIURL)
+	assert.Equal(t, "http://127.0.0.1:3000/", env.ClientURL)
+	assert.Equal(t, "http://127.0.0.1:3000/oauth/v1/", env.OauthURL)
+}
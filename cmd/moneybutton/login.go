@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/tonicpow/go-moneybutton"
+)
+
+// Login settings
+const (
+	callbackPath        = "/callback"
+	defaultLoginScopes  = "identity,profile,balance"
+	defaultLoginTimeout = 5 * time.Minute
+	envClientID         = "MONEYBUTTON_CLIENT_ID"
+)
+
+// scopeAliases are the short names of the oAuth permissions
+var scopeAliases = map[string]string{
+	"balance":  moneybutton.PermissionsBalance,
+	"identity": moneybutton.PermissionsIdentity,
+	"profile":  moneybutton.PermissionsProfile,
+}
+
+// callbackResult is the result of the oAuth redirect
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// runLogin will run the authorization code flow with PKCE using a loopback redirect listener
+func runLogin(ctx context.Context, a *app, args []string) error {
+	cfg, err := a.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	flags := a.newFlagSet("login")
+	baseURL := flags.String("base-url", "", "Base URL of a MoneyButton compatible server (default is production)")
+	clientID := flags.String("client-id", firstNonEmpty(os.Getenv(envClientID), cfg.ClientID), "oAuth client ID (or "+envClientID+")")
+	noBrowser := flags.Bool("no-browser", false, "Only print the authorization URL")
+	port := flags.Int("port", 0, "Port of the loopback redirect listener (0 is random)")
+	scopes := flags.String("scopes", defaultLoginScopes, "Comma separated scopes: identity, profile, balance (or full permissions)")
+	timeout := flags.Duration("timeout", defaultLoginTimeout, "How long to wait for the authorization")
+	if err = parseFlags(flags, args); err != nil {
+		return err
+	} else if len(*clientID) == 0 {
+		_, _ = fmt.Fprintln(a.stderr, "missing required flag: -client-id")
+		flags.Usage()
+		return errUsage
+	}
+	if len(*baseURL) > 0 {
+		cfg.Environment = environmentFromBaseURL(*baseURL)
+	}
+
+	// Start the redirect listener (loopback only)
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", *port))
+	if err != nil {
+		return err
+	}
+	redirectURI := "http://" + listener.Addr().String() + callbackPath
+
+	// Build the authorization URL
+	client := a.client(cfg)
+	pkce, err := moneybutton.NewPKCE()
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+	state := randomState()
+	authURL, err := client.GetAuthorizationURLWithPKCE(*clientID, redirectURI, parseScopes(*scopes), state, pkce)
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	results := make(chan callbackResult, 1)
+	server := &http.Server{Handler: callbackHandler(state, results), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer func() {
+		_ = server.Close()
+	}()
+
+	_, _ = fmt.Fprintf(a.stderr, "Open this URL to log in (redirect URI: %s):\n\n  %s\n\n", redirectURI, authURL)
+	if !*noBrowser {
+		if err = a.openBrowser(authURL); err != nil {
+			_, _ = fmt.Fprintf(a.stderr, "Could not open the browser: %s\n", err.Error())
+		}
+	}
+
+	// Wait for the redirect
+	var result callbackResult
+	timer := time.NewTimer(*timeout)
+	defer timer.Stop()
+	select {
+	case result = <-results:
+	case <-timer.C:
+		return errors.New("timed out waiting for the authorization")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if result.err != nil {
+		return result.err
+	}
+
+	// Exchange the code and save the tokens (keyed by the user ID)
+	token, err := client.GetRefreshTokenWithPKCE(ctx, *clientID, result.code, redirectURI, pkce.CodeVerifier)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(a.configDir, 0o700); err != nil {
+		return err
+	}
+	store, err := a.tokenStore()
+	if err != nil {
+		return err
+	}
+	identity, err := client.StoreToken(ctx, store, token)
+	if err != nil {
+		return err
+	}
+	user := identity.Identity()
+	if user == nil {
+		return fmt.Errorf("missing identity in the response")
+	}
+	cfg.ClientID, cfg.UserID = *clientID, identity.UserID()
+	if err = a.saveConfig(cfg); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(a.stderr, "Logged in as %s (%s)\n", user.Name, user.ID)
+	return nil
+}
+
+// callbackHandler will handle the oAuth redirect and send the result (once)
+//
+// Redirects with another state are rejected and not sent (the login keeps waiting for the real redirect)
+func callbackHandler(state string, results chan<- callbackResult) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != callbackPath {
+			http.NotFound(w, req)
+			return
+		}
+
+		query := req.URL.Query()
+		if query.Get("state") != state {
+			http.Error(w, "Login failed: invalid state on the redirect", http.StatusBadRequest)
+			return
+		}
+
+		var result callbackResult
+		switch {
+		case len(query.Get("error")) > 0:
+			result.err = fmt.Errorf("authorization failed: %s", query.Get("error"))
+		case len(query.Get("code")) == 0:
+			result.err = errors.New("missing code on the redirect")
+		default:
+			result.code = query.Get("code")
+		}
+
+		if result.err != nil {
+			http.Error(w, "Login failed: "+result.err.Error(), http.StatusBadRequest)
+		} else {
+			_, _ = fmt.Fprintln(w, "Logged in, you can close this window.")
+		}
+		select {
+		case results <- result:
+		default:
+		}
+	})
+}
+
+// parseScopes will parse the comma separated scopes (short names are expanded)
+func parseScopes(scopes string) moneybutton.Scopes {
+	var parsed moneybutton.Scopes
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope = strings.TrimSpace(scope); len(scope) == 0 {
+			continue
+		} else if permission, ok := scopeAliases[scope]; ok {
+			scope = permission
+		}
+		parsed = append(parsed, scope)
+	}
+	return parsed
+}
+
+// randomState will return a random state for the authorization request
+func randomState() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// openBrowser will open the URL in the default browser
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start() //nolint:gosec // the URL is built by the client
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start() //nolint:gosec // the URL is built by the client
+	default:
+		return exec.Command("xdg-open", url).Start() //nolint:gosec // the URL is built by the client
+	}
+}
+
+// firstNonEmpty will return the first value that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if len(value) > 0 {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tonicpow/go-moneybutton"
+	"github.com/tonicpow/go-moneybutton/moneybuttontest"
+)
+
+// TestLogin tests the login command
+func TestLogin(t *testing.T) {
+	t.Parallel()
+
+	t.Run("tokens and config are saved", func(t *testing.T) {
+		cli := newTestCLI(t)
+		cli.login(t)
+		assert.Contains(t, cli.stderr.String(), "Open this URL to log in")
+		assert.Contains(t, cli.stderr.String(), "Logged in as MrZ (123)")
+
+		a := &app{configDir: cli.configDir}
+		cfg, err := a.loadConfig()
+		require.NoError(t, err)
+		assert.Equal(t, testClientID, cfg.ClientID)
+		assert.Equal(t, "123", cfg.UserID)
+		require.NotNil(t, cfg.Environment)
+		assert.Equal(t, cli.server.URL()+"/api/v1/", cfg.Environment.APIURL)
+
+		store, err := a.tokenStore()
+		require.NoError(t, err)
+		token, err := store.Get(context.Background(), "123")
+		require.NoError(t, err)
+		assert.NotEmpty(t, token.RefreshToken)
+		assert.False(t, token.Expiry.IsZero())
+
+		info, err := os.Stat(filepath.Join(cli.configDir, tokensFile))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+
+	t.Run("client id from the config", func(t *testing.T) {
+		cli := newTestCLI(t)
+		cli.login(t)
+		assert.Equal(t, 0, cli.run("login", "-timeout", "5s"), cli.stderr.String())
+	})
+
+	t.Run("missing client id", func(t *testing.T) {
+		cli := newTestCLI(t)
+		assert.Equal(t, 2, cli.run("login", "-base-url", cli.server.URL()))
+		assert.Contains(t, cli.stderr.String(), "missing required flag: -client-id")
+	})
+
+	t.Run("user denies", func(t *testing.T) {
+		cli := newTestCLI(t)
+		cli.server.SetConsent(&moneybuttontest.Consent{UserID: "123"})
+		assert.Equal(t, 1, cli.run("login", "-client-id", testClientID, "-base-url", cli.server.URL(), "-timeout", "5s"))
+		assert.Contains(t, cli.stderr.String(), "authorization failed: access_denied")
+
+		_, err := os.Stat(filepath.Join(cli.configDir, configFile))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("empty identity", func(t *testing.T) {
+		cli := newTestCLI(t)
+		cli.server.RespondNext(moneybuttontest.EndpointUserIdentity, http.StatusOK,
+			`{"data":{"type":"user_identities","id":"123"}}`)
+		assert.Equal(t, 1, cli.run("login", "-client-id", testClientID, "-base-url", cli.server.URL(), "-timeout", "5s"))
+		assert.Contains(t, cli.stderr.String(), "missing identity in the response")
+
+		_, err := os.Stat(filepath.Join(cli.configDir, configFile))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("forged redirect is ignored", func(t *testing.T) {
+		cli := newTestCLI(t)
+		a := newApp(&cli.stdout, &cli.stderr)
+		a.openBrowser = func(authURL string) error {
+			u, err := url.Parse(authURL)
+			if err != nil {
+				return err
+			}
+			resp, err := http.Get(u.Query().Get("redirect_uri") + "?code=forged&state=other") //nolint:gosec // loopback test server
+			if err != nil {
+				return err
+			}
+			_ = resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			return cli.browse(authURL)
+		}
+		code := a.run(context.Background(), []string{
+			"-config-dir", cli.configDir, "login", "-client-id", testClientID, "-base-url", cli.server.URL(), "-timeout", "5s",
+		})
+		require.Equal(t, 0, code, cli.stderr.String())
+		assert.Contains(t, cli.stderr.String(), "Logged in as MrZ (123)")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		cli := newTestCLI(t)
+		assert.Equal(t, 1, cli.run(
+			"login", "-client-id", testClientID, "-base-url", cli.server.URL(), "-no-browser", "-timeout", "10ms",
+		))
+		assert.Contains(t, cli.stderr.String(), "timed out waiting for the authorization")
+	})
+
+	t.Run("invalid port", func(t *testing.T) {
+		cli := newTestCLI(t)
+		assert.Equal(t, 1, cli.run("login", "-client-id", testClientID, "-port", "-1"))
+	})
+}
+
+// TestCallbackHandler tests the method callbackHandler()
+func TestCallbackHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		target string
+		code   string
+		err    string
+	}{
+		{"valid", "/callback?code=abc&state=xyz", "abc", ""},
+		{"error", "/callback?error=access_denied&state=xyz", "", "authorization failed: access_denied"},
+		{"missing code", "/callback?state=xyz", "", "missing code on the redirect"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := make(chan callbackResult, 1)
+			w := httptest.NewRecorder()
+			callbackHandler("xyz", results).ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.target, nil))
+
+			result := <-results
+			assert.Equal(t, test.code, result.code)
+			if len(test.err) > 0 {
+				assert.EqualError(t, result.err, test.err)
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			} else {
+				assert.NoError(t, result.err)
+				assert.Equal(t, http.StatusOK, w.Code)
+			}
+		})
+	}
+
+	t.Run("invalid state is not sent", func(t *testing.T) {
+		results := make(chan callbackResult, 1)
+		handler := callbackHandler("xyz", results)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/callback?code=abc&state=other", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid state on the redirect")
+		assert.Len(t, results, 0)
+
+		// The real redirect is still accepted
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/callback?code=abc&state=xyz", nil))
+		assert.Equal(t, "abc", (<-results).code)
+	})
+
+	t.Run("other paths and repeated redirects", func(t *testing.T) {
+		results := make(chan callbackResult, 1)
+		handler := callbackHandler("xyz", results)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/favicon.ico", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/callback?code=1&state=xyz", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/callback?code=2&state=xyz", nil))
+		assert.Equal(t, "1", (<-results).code)
+		assert.Len(t, results, 0)
+	})
+}
+
+// TestParseScopes tests the method parseScopes()
+func TestParseScopes(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, moneybutton.Scopes{
+		moneybutton.PermissionsIdentity, moneybutton.PermissionsProfile, moneybutton.PermissionsBalance,
+	}, parseScopes(defaultLoginScopes))
+	assert.Equal(t, moneybutton.Scopes{moneybutton.PermissionsIdentity, "custom:read"}, parseScopes(" identity, ,custom:read"))
+	assert.Nil(t, parseScopes(""))
+}
@@ -0,0 +1,148 @@
+// Command moneybutton is a command-line client for the MoneyButton API
+//
+// Log in once (the tokens are saved in the config directory and refreshed automatically),
+// then call the API:
+//
+//	moneybutton login -client-id my-client-id
+//	moneybutton whoami
+//	moneybutton profile
+//	moneybutton balance
+//	moneybutton payments -status COMPLETED -limit 10
+//	moneybutton -output json payments
+//	moneybutton refresh
+//	moneybutton -verbose whoami
+//
+// The redirect URI (IE: http://127.0.0.1:8080/callback) must be registered for the client
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+)
+
+// Output formats
+const (
+	outputJSON  = "json"
+	outputTable = "table"
+)
+
+// errUsage is returned when the command line is invalid (the usage is already printed)
+var errUsage = errors.New("invalid usage")
+
+// command is a subcommand of the CLI
+type command struct {
+	run     func(ctx context.Context, a *app, args []string) error
+	summary string
+}
+
+// commands are all the subcommands (by name)
+var commands = map[string]*command{
+	"balance":  {run: runBalance, summary: "Show the balance of the user"},
+	"login":    {run: runLogin, summary: "Log in with MoneyButton (oAuth with PKCE) and save the tokens"},
+	"payments": {run: runPayments, summary: "List the payments of the user"},
+	"profile":  {run: runProfile, summary: "Show the profile of the user"},
+	"refresh":  {run: runRefresh, summary: "Refresh the access token"},
+	"whoami":   {run: runWhoami, summary: "Show the identity of the logged in user"},
+}
+
+// app holds the global settings of the CLI
+type app struct {
+	configDir   string
+	openBrowser func(url string) error
+	output      string
+	stderr      io.Writer
+	stdout      io.Writer
+	verbose     bool
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := newApp(os.Stdout, os.Stderr).run(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+// newApp will create the CLI writing to stdout and stderr
+func newApp(stdout, stderr io.Writer) *app {
+	return &app{openBrowser: openBrowser, stderr: stderr, stdout: stdout}
+}
+
+// run will run the CLI and return the exit code
+func (a *app) run(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("moneybutton", flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.StringVar(&a.configDir, "config-dir", defaultConfigDir(), "Directory of the config and token files")
+	flags.StringVar(&a.output, "output", outputTable, "Output format: table or json")
+	flags.BoolVar(&a.verbose, "verbose", false, "Log every API request to stderr")
+	flags.Usage = func() { a.usage(flags) }
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if a.output != outputJSON && a.output != outputTable {
+		_, _ = fmt.Fprintf(a.stderr, "moneybutton: invalid output format: %s\n", a.output)
+		return 2
+	} else if flags.NArg() == 0 {
+		a.usage(flags)
+		return 2
+	}
+
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		_, _ = fmt.Fprintf(a.stderr, "moneybutton: unknown command: %s\n", flags.Arg(0))
+		a.usage(flags)
+		return 2
+	}
+
+	if err := cmd.run(ctx, a, flags.Args()[1:]); errors.Is(err, errUsage) {
+		return 2
+	} else if err != nil {
+		_, _ = fmt.Fprintf(a.stderr, "moneybutton: %s\n", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// usage will print the global usage
+func (a *app) usage(flags *flag.FlagSet) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("Usage: moneybutton [flags] <command> [command flags]\n\nCommands:\n")
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("  %-10s %s\n", name, commands[name].summary))
+	}
+	b.WriteString("\nFlags:\n")
+	_, _ = io.WriteString(a.stderr, b.String())
+	flags.PrintDefaults()
+}
+
+// newFlagSet will return the flags for a command (errors and usage are printed to stderr)
+func (a *app) newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("moneybutton "+name, flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	return flags
+}
+
+// parseFlags will parse the command flags (no positional arguments are allowed)
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	} else if flags.NArg() > 0 {
+		_, _ = fmt.Fprintf(flags.Output(), "unexpected arguments: %s\n", strings.Join(flags.Args(), " "))
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
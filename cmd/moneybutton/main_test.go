@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tonicpow/go-moneybutton/moneybuttontest"
+)
+
+// testClientID is the client ID used in tests
+const testClientID = "test-client"
+
+// testCLI runs the CLI against a fake MoneyButton server (the output is captured)
+type testCLI struct {
+	configDir string
+	server    *moneybuttontest.Server
+	stderr    bytes.Buffer
+	stdout    bytes.Buffer
+}
+
+// newTestCLI will start a fake server with user 123 (the user approves every login)
+func newTestCLI(t *testing.T) *testCLI {
+	server := moneybuttontest.NewServer()
+	t.Cleanup(server.Close)
+	server.AddUser(&moneybuttontest.User{
+		Balance:         moneybuttontest.Balance{Amount: "12.34", Currency: "USD", Satoshis: 5000000},
+		Bio:             "I like Money Button.",
+		CreatedAt:       time.Date(2019, 3, 26, 17, 33, 42, 0, time.UTC),
+		DefaultCurrency: "USD",
+		DefaultLanguage: "en",
+		ID:              "123",
+		Name:            "MrZ",
+		PrimaryPaymail:  "mrz@moneybutton.com",
+	})
+	server.SetConsent(&moneybuttontest.Consent{Approve: true, UserID: "123"})
+	return &testCLI{configDir: t.TempDir(), server: server}
+}
+
+// run will run the CLI with the test config directory and return the exit code
+func (c *testCLI) run(args ...string) int {
+	c.stdout.Reset()
+	c.stderr.Reset()
+	a := newApp(&c.stdout, &c.stderr)
+	a.openBrowser = c.browse
+	return a.run(context.Background(), append([]string{"-config-dir", c.configDir}, args...))
+}
+
+// login will log in as user 123
+func (c *testCLI) login(t *testing.T) {
+	code := c.run("login", "-client-id", testClientID, "-base-url", c.server.URL(), "-timeout", "5s")
+	require.Equal(t, 0, code, c.stderr.String())
+}
+
+// browse will act as the browser: register the redirect URI, authorize and follow the redirect
+func (c *testCLI) browse(authURL string) error {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return err
+	}
+	c.server.AddApp(&moneybuttontest.App{
+		ClientID: u.Query().Get("client_id"), RedirectURIs: []string{u.Query().Get("redirect_uri")},
+	})
+
+	redirect, err := c.server.Authorize(authURL)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Get(redirect.String()) //nolint:gosec // loopback test server
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// TestRun tests the global flags and commands
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	t.Run("usage", func(t *testing.T) {
+		cli := newTestCLI(t)
+		assert.Equal(t, 2, cli.run())
+		assert.Contains(t, cli.stderr.String(), "Usage: moneybutton")
+		for name := range commands {
+			assert.Contains(t, cli.stderr.String(), name)
+		}
+
+		assert.Equal(t, 2, cli.run("-h"))
+		assert.Contains(t, cli.stderr.String(), "-config-dir")
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		cli := newTestCLI(t)
+		assert.Equal(t, 2, cli.run("unknown"))
+		assert.Contains(t, cli.stderr.String(), "unknown command: unknown")
+	})
+
+	t.Run("invalid output", func(t *testing.T) {
+		cli := newTestCLI(t)
+		assert.Equal(t, 2, cli.run("-output", "xml", "whoami"))
+		assert.Contains(t, cli.stderr.String(), "invalid output format: xml")
+	})
+
+	t.Run("invalid command flags", func(t *testing.T) {
+		cli := newTestCLI(t)
+		assert.Equal(t, 2, cli.run("whoami", "-unknown"))
+		assert.Equal(t, 2, cli.run("whoami", "extra"))
+		assert.Contains(t, cli.stderr.String(), "unexpected arguments: extra")
+	})
+
+	t.Run("not logged in", func(t *testing.T) {
+		cli := newTestCLI(t)
+		for _, name := range []string{"whoami", "profile", "balance", "payments", "refresh"} {
+			assert.Equal(t, 1, cli.run(name), name)
+			assert.Contains(t, cli.stderr.String(), "not logged in", name)
+		}
+	})
+
+	t.Run("api errors", func(t *testing.T) {
+		cli := newTestCLI(t)
+		cli.login(t)
+		cli.server.FailNext(moneybuttontest.EndpointUserIdentity, http.StatusForbidden, "Forbidden: insufficient scope")
+		assert.Equal(t, 1, cli.run("whoami"))
+		assert.Contains(t, cli.stderr.String(), "Forbidden: insufficient scope")
+	})
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// table is the table output of a command
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// print will write the value as JSON or the table (depending on the output format)
+func (a *app) print(value interface{}, t *table) error {
+	if a.output == outputJSON {
+		encoder := json.NewEncoder(a.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	if len(t.header) > 0 {
+		_, _ = fmt.Fprintln(w, strings.Join(t.header, "\t"))
+	}
+	for _, row := range t.rows {
+		_, _ = fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// fieldTable will return a two column table of field names and values
+func fieldTable(fields ...string) *table {
+	t := new(table)
+	for i := 0; i+1 < len(fields); i += 2 {
+		t.rows = append(t.rows, []string{fields[i] + ":", fields[i+1]})
+	}
+	return t
+}
+
+// formatTime will format the time for the table output (empty if not set)
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApp_Print tests the method print()
+func TestApp_Print(t *testing.T) {
+	t.Parallel()
+
+	value := map[string]string{"id": "123"}
+	t.Run("table", func(t *testing.T) {
+		var stdout bytes.Buffer
+		a := &app{output: outputTable, stdout: &stdout}
+		require.NoError(t, a.print(value, &table{header: []string{"ID", "NAME"}, rows: [][]string{{"123", "MrZ"}, {"4", "Other"}}}))
+		assert.Equal(t, "ID   NAME\n123  MrZ\n4    Other\n", stdout.String())
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		var stdout bytes.Buffer
+		a := &app{output: outputTable, stdout: &stdout}
+		require.NoError(t, a.print(value, fieldTable("ID", "123", "Name")))
+		assert.Equal(t, "ID:  123\n", stdout.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var stdout bytes.Buffer
+		a := &app{output: outputJSON, stdout: &stdout}
+		require.NoError(t, a.print(value, fieldTable("ID", "123")))
+		assert.Equal(t, "{\n  \"id\": \"123\"\n}\n", stdout.String())
+	})
+}
+
+// TestFormatTime tests the method formatTime()
+func TestFormatTime(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", formatTime(time.Time{}))
+	assert.Equal(t, "2021-01-02T03:04:05Z", formatTime(time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)))
+}
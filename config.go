@@ -1,5 +1,7 @@
 package moneybutton
 
+import "time"
+
 const (
 
 	// version is the current package version
@@ -15,21 +17,54 @@ const (
 	grantTypeAuthorizationCode  = "authorization_code"
 	grantTypeRefreshAccessToken = "refresh_token"
 
-	// endpoints
-	endpointToken        = OauthURL + "token"
-	endpointUserIdentity = APIURL + "auth/user_identity"
-	endpointUserProfile  = APIURL + "users/%s/profile" // requires fmt.Sprintf(endpointUserProfile,userID)
+	// endpoints (relative to the Environment OauthURL or APIURL)
+	endpointAuthorize    = "authorize"
+	endpointPayment      = "payments/%s" // requires fmt.Sprintf(endpointPayment,paymentID)
+	endpointPayments     = "payments"
+	endpointToken        = "token"
+	endpointUserBalance  = "users/%s/balance" // requires fmt.Sprintf(endpointUserBalance,userID)
+	endpointUserIdentity = "auth/user_identity"
+	endpointUserProfile  = "users/%s/profile" // requires fmt.Sprintf(endpointUserProfile,userID)
+
+	// JSON:API relationships
+	relationshipPaymentOutputs = "payment-outputs"
 
 	// authorization header
 	authHeaderBearer = "Bearer"
+
+	// response type for the authorization code flow
+	responseTypeCode = "code"
+
+	// pagination (JSON:API page[number] and page[size])
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	// defaultTokenExpirySkew is how early a TokenSource will refresh an access token before it expires
+	defaultTokenExpirySkew = 60 * time.Second
+
+	// defaultTokenRefreshTimeout is the max time of a shared TokenSource refresh (not bound to any caller)
+	defaultTokenRefreshTimeout = 30 * time.Second
+
+	// defaultWebhookMaxBodySize is the max size (in bytes) of a webhook body
+	defaultWebhookMaxBodySize = 1 << 20
+
+	// PKCE (RFC 7636) settings
+	pkceChallengeMethodS256 = "S256"
+	pkceVerifierBytes       = 32 // 43 characters once encoded
+	pkceVerifierMaxLength   = 128
+	pkceVerifierMinLength   = 43
 )
 
 // Public constants used for MoneyButton
 //
 // Specs: https://docs.moneybutton.com/docs/api-overview.html
 const (
-	APIURL   = "https://www.moneybutton.com/api/" + apiVersion + "/"
-	OauthURL = "https://www.moneybutton.com/oauth/" + apiVersion + "/"
+	APIURL    = "https://www.moneybutton.com/api/" + apiVersion + "/"
+	ClientURL = "https://www.moneybutton.com/"
+	OauthURL  = "https://www.moneybutton.com/oauth/" + apiVersion + "/"
+
+	// EnvironmentProduction is the name of the default (production) environment
+	EnvironmentProduction = "production"
 
 	// MoneyButton oAuth Permissions
 
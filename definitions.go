@@ -1,61 +1,135 @@
 package moneybutton
 
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
 // RefreshTokenResponse is used to get a refresh token for getting
 // user information from the moneybutton API
 //
 // Specs: https://docs.moneybutton.com/docs/api-oauth-endpoints.html
 type RefreshTokenResponse struct {
-	AccessToken  string `json:"access_token"`
-	TokenType    string `json:"token_type"` // "Bearer"
-	ExpiresIn    uint32 `json:"expires_in"` // 3600  (default is 1 hour)
-	RefreshToken string `json:"refresh_token"`
-	Scope        string `json:"scope"`
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"` // "Bearer"
+	ExpiresIn    uint32    `json:"expires_in"` // 3600  (default is 1 hour)
+	RefreshToken string    `json:"refresh_token"`
+	Scope        string    `json:"scope"`
+	Expiry       time.Time `json:"expiry"` // Absolute expiry (not returned by MoneyButton, set by the TokenSource)
 }
 
 // UserIdentity is the user identity
 //
 // Specs: https://docs.moneybutton.com/docs/api-rest-user-identity.html
 type UserIdentity struct {
-	Data    *userIdentityData `json:"data"`
-	JSONAPI *jsonAPIVersion   `json:"jsonapi"`
-}
-
-// userIdentityAttributes is used in identity data
-type userIdentityAttributes struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	Data    *ResourceObject[Identity] `json:"data"`
+	JSONAPI *jsonAPIVersion           `json:"jsonapi"`
+	Links   Links                     `json:"links,omitempty"`
+	Meta    Meta                      `json:"meta,omitempty"`
 }
 
-// userIdentityData is the identity data
-type userIdentityData struct {
-	Attributes *userIdentityAttributes `json:"attributes"`
-	ID         string                  `json:"id"`
-	Type       string                  `json:"type"`
+// Identity is the minimum data to identify a user
+type Identity struct {
+	ID   string `json:"id"`   // User ID
+	Name string `json:"name"` // Display name
 }
 
 // UserProfile is the user fields returned for the user profile
 //
 // Specs: https://docs.moneybutton.com/docs/api-rest-user-profile.html
 type UserProfile struct {
-	Data *userProfileData `json:"data"`
+	Data    *ResourceObject[Profile] `json:"data"`
+	JSONAPI *jsonAPIVersion          `json:"jsonapi"`
+	Links   Links                    `json:"links,omitempty"`
+	Meta    Meta                     `json:"meta,omitempty"`
+}
+
+// Profile is the public profile of a user
+//
+// Fields are parsed when decoded (see: Profile.UnmarshalJSON), a value that cannot
+// be parsed is kept in the Raw field and does not fail the whole profile
+type Profile struct {
+	AvatarURL       *url.URL  `json:"avatar-url"`       // Avatar image (nil if not set or invalid)
+	Bio             string    `json:"bio"`              // IE: I like Money Button.
+	CreatedAt       time.Time `json:"created-at"`       // When the user was created (zero if not set or invalid)
+	DefaultCurrency string    `json:"default-currency"` // IE: USD
+	DefaultLanguage string    `json:"default-language"` // IE: en
+	Name            string    `json:"name"`             // Display name
+	PrimaryPaymail  Paymail   `json:"primary-paymail"`  // IE: mrz@moneybutton.com (see: Paymail.Valid())
+	RawAvatarURL    string    `json:"-"`                // The avatar-url if it could not be parsed
+	RawCreatedAt    string    `json:"-"`                // The created-at if it could not be parsed
 }
 
-// userProfileAttributes
-type userProfileAttributes struct {
-	AvatarURL       string `json:"avatar-url"`
-	Bio             string `json:"bio"`
-	CreatedAt       string `json:"created-at"`
-	DefaultCurrency string `json:"default-currency"`
-	DefaultLanguage string `json:"default-language"`
-	Name            string `json:"name"`
-	PrimaryPaymail  string `json:"primary-paymail"`
+// UserBalance is the user's balance (in their default currency and in satoshis)
+//
+// Specs: https://docs.moneybutton.com/docs/api-rest-user-balance.html
+type UserBalance struct {
+	Data    *ResourceObject[Balance] `json:"data"`
+	JSONAPI *jsonAPIVersion          `json:"jsonapi"`
+	Links   Links                    `json:"links,omitempty"`
+	Meta    Meta                     `json:"meta,omitempty"`
+}
+
+// Balance is the amount of the user balance (the satoshis can be a JSON number or string)
+type Balance struct {
+	Amount   json.Number `json:"amount"`   // Amount in the currency (IE: 12.34)
+	Currency string      `json:"currency"` // IE: USD
+	Satoshis json.Number `json:"satoshis"` // Amount in satoshis
 }
 
-// userProfileData
-type userProfileData struct {
-	Attributes *userProfileAttributes `json:"attributes"`
-	ID         string                 `json:"id"`
-	Type       string                 `json:"type"`
+// Payment is a single payment
+//
+// Specs: https://docs.moneybutton.com/docs/api-rest-payments.html
+type Payment struct {
+	Data    *PaymentData    `json:"data"`
+	JSONAPI *jsonAPIVersion `json:"jsonapi"`
+	Links   Links           `json:"links,omitempty"`
+	Meta    Meta            `json:"meta,omitempty"`
+}
+
+// Payments is a single page of payments
+//
+// Specs: https://docs.moneybutton.com/docs/api-rest-payments.html
+type Payments struct {
+	Data    []*PaymentData  `json:"data"`
+	JSONAPI *jsonAPIVersion `json:"jsonapi"`
+	Links   Links           `json:"links,omitempty"` // IE: self, next, prev (use Links.Href("next"))
+	Meta    Meta            `json:"meta,omitempty"`
+}
+
+// PaymentAttributes are the fields of a payment
+type PaymentAttributes struct {
+	Amount            json.Number      `json:"amount"`             // Amount in the currency (IE: 0.01)
+	AmountUSD         json.Number      `json:"amount-usd"`         // Amount in USD
+	ButtonData        string           `json:"button-data"`        // Custom data set on the button
+	ButtonID          string           `json:"button-id"`          // Custom ID set on the button
+	CreatedAt         time.Time        `json:"created-at"`         // When the payment was created
+	Currency          string           `json:"currency"`           // IE: USD
+	NormalizedTxID    string           `json:"normalized-txid"`    // Normalized transaction ID
+	PaymentOutputs    []*PaymentOutput `json:"payment-outputs"`    // All outputs of the payment
+	Satoshis          json.Number      `json:"satoshis"`           // Amount in satoshis
+	Status            PaymentStatus    `json:"status"`             // IE: COMPLETED
+	StatusDescription string           `json:"status-description"` // Description of the status (if any)
+	TxID              string           `json:"txid"`               // Transaction ID
+	UpdatedAt         time.Time        `json:"updated-at"`         // When the payment was last updated
+	UserID            string           `json:"user-id"`            // The user that made the payment
+}
+
+// PaymentData is the payment resource
+type PaymentData = ResourceObject[PaymentAttributes]
+
+// PaymentOutput is a single output of a payment
+type PaymentOutput struct {
+	Address  string      `json:"address"`  // Bitcoin address (if paid to an address)
+	Amount   json.Number `json:"amount"`   // Amount in the currency
+	Currency string      `json:"currency"` // IE: USD
+	ID       string      `json:"id"`       // Output ID
+	Satoshis json.Number `json:"satoshis"` // Amount in satoshis
+	Script   string      `json:"script"`   // Output script (if paid to a script)
+	To       string      `json:"to"`       // Paymail, user ID, address or script
+	Type     string      `json:"type"`     // IE: USER, ADDRESS, PAYMAIL, SCRIPT
+	UserID   string      `json:"user-id"`  // Receiving user (if paid to a user)
 }
 
 /*
@@ -74,14 +148,8 @@ type userProfileData struct {
 }
 */
 
-// errorResponse is the error response from the MoneyButton API
-type errorResponse struct {
-	Errors  []*apiError     `json:"errors"`
-	JSONAPI *jsonAPIVersion `json:"jsonapi"`
-}
-
-// apiError is the individual error
-type apiError struct {
+// ErrorObject is an individual JSON:API error returned by the MoneyButton API
+type ErrorObject struct {
 	Detail string `json:"detail"`
 	ID     string `json:"id"`
 	Status int    `json:"status"`
@@ -0,0 +1,136 @@
+package moneybutton
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors for common oAuth failures (use errors.Is() on any error returned by the Client)
+//
+// These failures will not succeed on a retry, the user needs to authorize the application again
+var (
+	// ErrAuthCodeExpired is returned when the authorization code has expired, was already used or is invalid
+	ErrAuthCodeExpired = errors.New("authorization code has expired or is invalid")
+
+	// ErrInsufficientScope is returned when the access token does not have the required permissions
+	ErrInsufficientScope = errors.New("insufficient scope")
+
+	// ErrInvalidRefreshToken is returned when the refresh token has expired, was revoked or is invalid
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+	// ErrUnauthorized is returned when the access token is missing, expired or invalid
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// APIError is returned when the MoneyButton API responds with an unexpected status code
+//
+// Use errors.As() to get the status code and the individual JSON:API error objects
+type APIError struct {
+	Errors     []*ErrorObject `json:"errors"`      // All errors returned (can be empty)
+	Method     string         `json:"method"`      // Method is the HTTP method used
+	StatusCode int            `json:"status_code"` // StatusCode is the HTTP status returned
+	URL        string         `json:"url"`         // URL is used for the request
+}
+
+// newAPIError will create an APIError from the response (parsing any JSON:API errors in the body)
+func newAPIError(response *RequestResponse) *APIError {
+	apiErr := &APIError{
+		Method:     response.Method,
+		StatusCode: response.StatusCode,
+		URL:        response.URL,
+	}
+
+	// Parse the errors (if the body is not a JSON:API error document, the errors are left empty)
+	if len(response.BodyContents) > 0 {
+		if doc, err := DecodeDocument(response.BodyContents); err == nil {
+			for _, errObj := range doc.Errors {
+				if errObj != nil {
+					apiErr.Errors = append(apiErr.Errors, errObj)
+				}
+			}
+		}
+	}
+	return apiErr
+}
+
+// Error will return all the error details returned by the API
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: request failed with status code: %d", e.Method, e.URL, e.StatusCode)
+	if len(e.Errors) == 0 {
+		return msg
+	}
+
+	details := make([]string, 0, len(e.Errors))
+	for _, errObj := range e.Errors {
+		details = append(details, errObj.String())
+	}
+	return msg + ": " + strings.Join(details, "; ")
+}
+
+// Is will match the error to the oAuth sentinel errors (IE: errors.Is(err, ErrAuthCodeExpired))
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrAuthCodeExpired, ErrInvalidRefreshToken, ErrInsufficientScope, ErrUnauthorized:
+	default:
+		return false
+	}
+
+	// Match on the individual errors first
+	for _, errObj := range e.Errors {
+		if errObj.sentinel() == target {
+			return true
+		}
+	}
+
+	// Fall back to the status code
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case http.StatusForbidden:
+		return target == ErrInsufficientScope
+	}
+	return false
+}
+
+// ErrorIDs will return the MoneyButton ID of every error (useful for logging and support requests)
+func (e *APIError) ErrorIDs() []string {
+	ids := make([]string, 0, len(e.Errors))
+	for _, errObj := range e.Errors {
+		if len(errObj.ID) > 0 {
+			ids = append(ids, errObj.ID)
+		}
+	}
+	return ids
+}
+
+// String will return the detail (or title) of the error and its ID
+func (e *ErrorObject) String() string {
+	msg := e.Detail
+	if len(msg) == 0 {
+		msg = e.Title
+	}
+	if len(e.ID) > 0 {
+		msg += " (id: " + e.ID + ")"
+	}
+	return msg
+}
+
+// sentinel will map the error to a sentinel error using the detail, title and status (nil if unknown)
+//
+// Example detail: "Invalid grant: authorization code has expired"
+func (e *ErrorObject) sentinel() error {
+	text := strings.ToLower(e.Detail + " " + e.Title)
+	switch {
+	case strings.Contains(text, "authorization code"):
+		return ErrAuthCodeExpired
+	case strings.Contains(text, "refresh token"):
+		return ErrInvalidRefreshToken
+	case strings.Contains(text, "scope"), e.Status == http.StatusForbidden:
+		return ErrInsufficientScope
+	case strings.Contains(text, "unauthorized"), e.Status == http.StatusUnauthorized:
+		return ErrUnauthorized
+	}
+	return nil
+}
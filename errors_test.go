@@ -0,0 +1,215 @@
+package moneybutton
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHTTPErrorResponse for mocking requests
+type mockHTTPErrorResponse struct {
+	body   string
+	status int
+}
+
+// Do is a mock http request
+func (m *mockHTTPErrorResponse) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+	resp.StatusCode = m.status
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(m.body)))
+
+	// Default is valid
+	return resp, nil
+}
+
+// TestAPIError tests the APIError returned from requests
+func TestAPIError(t *testing.T) {
+	t.Parallel()
+
+	t.Run("single error", func(t *testing.T) {
+		client := newTestClient(&mockHTTPAPIError{})
+		_, err := client.GetRefreshToken(context.Background(), "1234567", "1234567", "http://domain.com")
+		require.Error(t, err)
+
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Equal(t, http.MethodPost, apiErr.Method)
+		assert.Equal(t, OauthURL+endpointToken, apiErr.URL)
+		require.Len(t, apiErr.Errors, 1)
+		assert.Equal(t, "ffb71830-409b-11eb-9032-37efc953c879", apiErr.Errors[0].ID)
+		assert.Equal(t, http.StatusBadRequest, apiErr.Errors[0].Status)
+		assert.Equal(t, "Bad Request", apiErr.Errors[0].Title)
+		assert.Equal(t, "Invalid grant: authorization code has expired", apiErr.Errors[0].Detail)
+		assert.Equal(t, []string{"ffb71830-409b-11eb-9032-37efc953c879"}, apiErr.ErrorIDs())
+		assert.Equal(
+			t,
+			"POST "+OauthURL+endpointToken+": request failed with status code: 400: Invalid grant: authorization code has expired (id: ffb71830-409b-11eb-9032-37efc953c879)",
+			err.Error(),
+		)
+	})
+
+	t.Run("multiple errors", func(t *testing.T) {
+		client := newTestClient(&mockHTTPErrorResponse{
+			status: http.StatusUnprocessableEntity,
+			body:   `{"errors":[{"id":"1","status":422,"title":"Unprocessable Entity","detail":"first"},{"status":422,"title":"Second"}],"jsonapi":{"version":"1.0"}}`,
+		})
+		_, err := client.GetUserIdentity(context.Background(), "1234567")
+		require.Error(t, err)
+
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+		assert.Equal(t, http.MethodGet, apiErr.Method)
+		assert.Len(t, apiErr.Errors, 2)
+		assert.Equal(t, []string{"1"}, apiErr.ErrorIDs())
+		assert.Equal(
+			t,
+			"GET "+APIURL+endpointUserIdentity+": request failed with status code: 422: first (id: 1); Second",
+			err.Error(),
+		)
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		client := newTestClient(&mockHTTPError{})
+		_, err := client.GetUserIdentity(context.Background(), "1234567")
+		require.Error(t, err)
+
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+		assert.Empty(t, apiErr.Errors)
+		assert.Equal(t, "GET "+APIURL+endpointUserIdentity+": request failed with status code: 500", err.Error())
+	})
+
+	t.Run("body is not json", func(t *testing.T) {
+		client := newTestClient(&mockHTTPErrorResponse{
+			status: http.StatusBadGateway,
+			body:   `<html>Bad Gateway</html>`,
+		})
+		_, err := client.GetProfile(context.Background(), "123", "1234567")
+		require.Error(t, err)
+
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+		assert.Empty(t, apiErr.Errors)
+	})
+}
+
+// TestAPIError_Is tests the method Is() for every API method
+func TestAPIError_Is(t *testing.T) {
+	t.Parallel()
+
+	// All the client methods that make requests
+	methods := map[string]func(client *Client) error{
+		"GetRefreshToken": func(client *Client) error {
+			_, err := client.GetRefreshToken(context.Background(), "1234567", "1234567", "http://domain.com")
+			return err
+		},
+		"GetRefreshTokenWithPKCE": func(client *Client) error {
+			_, err := client.GetRefreshTokenWithPKCE(context.Background(), "1234567", "1234567", "http://domain.com", rfcCodeVerifier)
+			return err
+		},
+		"RefreshAccessToken": func(client *Client) error {
+			_, err := client.RefreshAccessToken(context.Background(), "1234567", "1234567")
+			return err
+		},
+		"GetUserIdentity": func(client *Client) error {
+			_, err := client.GetUserIdentity(context.Background(), "1234567")
+			return err
+		},
+		"GetProfile": func(client *Client) error {
+			_, err := client.GetProfile(context.Background(), "123", "1234567")
+			return err
+		},
+	}
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected error
+	}{
+		{
+			name:     "authorization code expired",
+			status:   http.StatusBadRequest,
+			body:     `{"errors":[{"id":"1","status":400,"title":"Bad Request","detail":"Invalid grant: authorization code has expired"}]}`,
+			expected: ErrAuthCodeExpired,
+		},
+		{
+			name:     "authorization code invalid",
+			status:   http.StatusBadRequest,
+			body:     `{"errors":[{"id":"1","status":400,"title":"Bad Request","detail":"Invalid grant: authorization code is invalid"}]}`,
+			expected: ErrAuthCodeExpired,
+		},
+		{
+			name:     "invalid refresh token",
+			status:   http.StatusBadRequest,
+			body:     `{"errors":[{"id":"1","status":400,"title":"Bad Request","detail":"Invalid grant: refresh token is invalid"}]}`,
+			expected: ErrInvalidRefreshToken,
+		},
+		{
+			name:     "insufficient scope detail",
+			status:   http.StatusForbidden,
+			body:     `{"errors":[{"id":"1","status":403,"title":"Forbidden","detail":"Insufficient scope"}]}`,
+			expected: ErrInsufficientScope,
+		},
+		{
+			name:     "insufficient scope status",
+			status:   http.StatusForbidden,
+			body:     ``,
+			expected: ErrInsufficientScope,
+		},
+		{
+			name:     "unauthorized detail",
+			status:   http.StatusUnauthorized,
+			body:     `{"errors":[{"id":"1","status":401,"title":"Unauthorized","detail":"Invalid token: access token has expired"}]}`,
+			expected: ErrUnauthorized,
+		},
+		{
+			name:     "unauthorized status",
+			status:   http.StatusUnauthorized,
+			body:     `Unauthorized`,
+			expected: ErrUnauthorized,
+		},
+		{
+			name:     "transient error",
+			status:   http.StatusServiceUnavailable,
+			body:     `{"errors":[{"id":"1","status":503,"title":"Service Unavailable","detail":"Try again later"}]}`,
+			expected: nil,
+		},
+	}
+
+	sentinels := []error{ErrAuthCodeExpired, ErrInsufficientScope, ErrInvalidRefreshToken, ErrUnauthorized}
+
+	for methodName, method := range methods {
+		for _, test := range tests {
+			t.Run(methodName+": "+test.name, func(t *testing.T) {
+				err := method(newTestClient(&mockHTTPErrorResponse{status: test.status, body: test.body}))
+				require.Error(t, err)
+				for _, sentinel := range sentinels {
+					assert.Equal(t, sentinel == test.expected, errors.Is(err, sentinel), sentinel.Error())
+				}
+			})
+		}
+	}
+
+	t.Run("unrelated target", func(t *testing.T) {
+		apiErr := &APIError{StatusCode: http.StatusUnauthorized}
+		assert.False(t, apiErr.Is(errors.New("unauthorized")))
+	})
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/tonicpow/go-moneybutton"
+)
+
+func main() {
+	client := moneybutton.NewClient(nil, nil, nil)
+
+	response, err := client.GetPayment(
+		context.Background(),
+		os.Getenv("PAYMENT_ID"),
+		os.Getenv("ACCESS_TOKEN"),
+	)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	log.Println("payment: ", response.Data)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/tonicpow/go-moneybutton"
+)
+
+func main() {
+	client := moneybutton.NewClient(nil, nil, nil)
+
+	response, err := client.GetBalance(
+		context.Background(),
+		os.Getenv("USER_ID"),
+		os.Getenv("ACCESS_TOKEN"),
+	)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	log.Println("balance: ", response.Balance())
+}
@@ -9,7 +9,7 @@ import (
 )
 
 func main() {
-	client := moneybutton.NewClient(nil, nil)
+	client := moneybutton.NewClient(nil, nil, nil)
 
 	response, err := client.GetUserIdentity(
 		context.Background(),
@@ -18,5 +18,5 @@ func main() {
 	if err != nil {
 		log.Fatalln(err)
 	}
-	log.Println("identity: ", response.Data)
+	log.Println("identity: ", response.Identity())
 }
@@ -9,7 +9,7 @@ import (
 )
 
 func main() {
-	client := moneybutton.NewClient(nil, nil)
+	client := moneybutton.NewClient(nil, nil, nil)
 
 	response, err := client.GetProfile(
 		context.Background(),
@@ -19,5 +19,5 @@ func main() {
 	if err != nil {
 		log.Fatalln(err)
 	}
-	log.Println("profile: ", response.Data)
+	log.Println("profile: ", response.Profile())
 }
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/tonicpow/go-moneybutton"
+)
+
+func main() {
+	client := moneybutton.NewClient(nil, nil, nil)
+
+	it := client.PaymentIterator(
+		os.Getenv("ACCESS_TOKEN"),
+		&moneybutton.ListPaymentsOptions{Status: moneybutton.PaymentStatusCompleted},
+	)
+	for it.Next(context.Background()) {
+		log.Println("payment: ", it.Payment().ID)
+	}
+	if err := it.Err(); err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -9,7 +9,7 @@ import (
 )
 
 func main() {
-	client := moneybutton.NewClient(nil, nil)
+	client := moneybutton.NewClient(nil, nil, nil)
 
 	response, err := client.RefreshAccessToken(
 		context.Background(),
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 )
 
 /*
@@ -23,6 +24,41 @@ import (
 func (c *Client) GetRefreshToken(ctx context.Context, clientID, authCode,
 	redirectURI string) (*RefreshTokenResponse, error) {
 
+	// Check required parameters
+	form, err := refreshTokenForm(clientID, authCode, redirectURI)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.getRefreshToken(ctx, form)
+}
+
+// GetRefreshTokenWithPKCE will get a new refresh token given an auth code and the PKCE code verifier
+// that was used to create the code challenge on the authorization request (see: NewPKCE())
+//
+// Specs: https://datatracker.ietf.org/doc/html/rfc7636#section-4.5
+func (c *Client) GetRefreshTokenWithPKCE(ctx context.Context, clientID, authCode,
+	redirectURI, codeVerifier string) (*RefreshTokenResponse, error) {
+
+	// Check required parameters
+	form, err := refreshTokenForm(clientID, authCode, redirectURI)
+	if err != nil {
+		return nil, err
+	} else if len(codeVerifier) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "codeVerifier")
+	} else if err = validateCodeVerifier(codeVerifier); err != nil {
+		return nil, err
+	}
+
+	// Add the verifier
+	form.Set("code_verifier", codeVerifier)
+
+	return c.getRefreshToken(ctx, form)
+}
+
+// refreshTokenForm will validate and build the form for exchanging an auth code
+func refreshTokenForm(clientID, authCode, redirectURI string) (url.Values, error) {
+
 	// Check required parameters
 	if len(clientID) == 0 {
 		return nil, fmt.Errorf("missing required parameter: %s", "clientID")
@@ -32,16 +68,27 @@ func (c *Client) GetRefreshToken(ctx context.Context, clientID, authCode,
 		return nil, fmt.Errorf("missing required parameter: %s", "redirectURI")
 	}
 
+	form := url.Values{}
+	form.Set("grant_type", grantTypeAuthorizationCode)
+	form.Set("client_id", clientID)
+	form.Set("code", authCode)
+	form.Set("redirect_uri", redirectURI)
+	return form, nil
+}
+
+// getRefreshToken will fire the token request and decode the response
+func (c *Client) getRefreshToken(ctx context.Context, form url.Values) (*RefreshTokenResponse, error) {
+
 	// Fire the request
 	response := httpRequest(
 		ctx,
 		c,
 		&httpPayload{
-			Data: `grant_type=` + grantTypeAuthorizationCode + `&client_id=` + clientID +
-				`&code=` + authCode + `&redirect_uri=` + redirectURI,
+			Data:           form.Encode(),
+			Endpoint:       "GetRefreshToken",
 			ExpectedStatus: http.StatusOK,
 			Method:         http.MethodPost,
-			URL:            endpointToken,
+			URL:            c.oauthURL(endpointToken),
 		},
 	)
 
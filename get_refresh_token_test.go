@@ -24,7 +24,7 @@ func (m *mockHTTPGetRefreshToken) Do(req *http.Request) (*http.Response, error)
 		return resp, fmt.Errorf("missing request")
 	}
 
-	if req.URL.String() == endpointToken {
+	if req.URL.String() == OauthURL+endpointToken {
 		resp.StatusCode = http.StatusOK
 		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"access_token":"zzz0eXAiOiJKV1QiLCJhbGciOiJIUzI1NiJ9.eyJzdWIzOiIyNjQ1IiwiYXVkIjoiMjhmNzUyZDg0NmRmzjU0YzM3ZWQ1MjBlZTlkYjJjMzMiLCJleHAiOjE2MDgyMzcwOTEsInNjb3BlIjoidXNlcnMucHJvZmlsZXM6cmVhZCBhzzRoLnVzZXJfaWRlbnRpdHk6cmVhZCJ9.As1Mz5EbsqkvOekC_mzfsrVEcXX7oPHMzOVzzBQc-zz","token_type":"` + authHeaderBearer + `","expires_in":3599,"refresh_token":"z7696f8a9a92707zbc00a6ab74c474ae9acz8dc4d23125a3z40028a93e65az80","scope":"` + PermissionsIdentity + " " + PermissionsProfile + `"}`)))
 	}
@@ -33,6 +33,35 @@ func (m *mockHTTPGetRefreshToken) Do(req *http.Request) (*http.Response, error)
 	return resp, nil
 }
 
+// mockHTTPGetRefreshTokenPKCE for mocking requests (requires a valid code verifier)
+type mockHTTPGetRefreshTokenPKCE struct{}
+
+// Do is a mock http request
+func (m *mockHTTPGetRefreshTokenPKCE) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+	resp.StatusCode = http.StatusBadRequest
+	resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"errors":[{"id":"ffb71830-409b-11eb-9032-37efc953c879","status":400,"title":"Bad Request","detail":"Invalid grant: code verifier does not match"}],"jsonapi":{"version":"1.0"}}`)))
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	if err := req.ParseForm(); err != nil {
+		return resp, err
+	}
+
+	if req.URL.String() == OauthURL+endpointToken &&
+		req.PostForm.Get("grant_type") == grantTypeAuthorizationCode &&
+		req.PostForm.Get("code_verifier") == rfcCodeVerifier {
+		resp.StatusCode = http.StatusOK
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"access_token":"zzz0eXAiOiJKV1QiLCJhbGciOiJIUzI1NiJ9","token_type":"` + authHeaderBearer + `","expires_in":3599,"refresh_token":"z7696f8a9a92707zbc00a6ab74c474ae9acz8dc4d23125a3z40028a93e65az80","scope":"` + PermissionsIdentity + `"}`)))
+	}
+
+	// Default is valid
+	return resp, nil
+}
+
 // mockHTTPAPIError for mocking requests
 type mockHTTPAPIError struct{}
 
@@ -170,3 +199,90 @@ func TestClient_GetRefreshToken(t *testing.T) {
 		assert.Equal(t, authHeaderBearer, tokenResponse.TokenType)
 	})
 }
+
+// TestClient_GetRefreshTokenWithPKCE tests the method GetRefreshTokenWithPKCE()
+func TestClient_GetRefreshTokenWithPKCE(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing code verifier", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetRefreshTokenPKCE{})
+		tokenResponse, err := client.GetRefreshTokenWithPKCE(
+			context.Background(),
+			"1234567",
+			"1234567",
+			"http://domain.com",
+			"",
+		)
+		assert.Error(t, err)
+		assert.Nil(t, tokenResponse)
+	})
+
+	t.Run("invalid code verifier", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetRefreshTokenPKCE{})
+		tokenResponse, err := client.GetRefreshTokenWithPKCE(
+			context.Background(),
+			"1234567",
+			"1234567",
+			"http://domain.com",
+			"not-long-enough",
+		)
+		assert.Error(t, err)
+		assert.Nil(t, tokenResponse)
+	})
+
+	t.Run("missing client id", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetRefreshTokenPKCE{})
+		tokenResponse, err := client.GetRefreshTokenWithPKCE(
+			context.Background(),
+			"",
+			"1234567",
+			"http://domain.com",
+			rfcCodeVerifier,
+		)
+		assert.Error(t, err)
+		assert.Nil(t, tokenResponse)
+	})
+
+	t.Run("wrong code verifier", func(t *testing.T) {
+		pkce, err := NewPKCE()
+		assert.NoError(t, err)
+		client := newTestClient(&mockHTTPGetRefreshTokenPKCE{})
+		tokenResponse, err := client.GetRefreshTokenWithPKCE(
+			context.Background(),
+			"1234567",
+			"1234567",
+			"http://domain.com",
+			pkce.CodeVerifier,
+		)
+		assert.Error(t, err)
+		assert.Nil(t, tokenResponse)
+	})
+
+	t.Run("http error", func(t *testing.T) {
+		client := newTestClient(&mockHTTPError{})
+		tokenResponse, err := client.GetRefreshTokenWithPKCE(
+			context.Background(),
+			"1234567",
+			"1234567",
+			"http://domain.com",
+			rfcCodeVerifier,
+		)
+		assert.Error(t, err)
+		assert.Nil(t, tokenResponse)
+	})
+
+	t.Run("valid response", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetRefreshTokenPKCE{})
+		tokenResponse, err := client.GetRefreshTokenWithPKCE(
+			context.Background(),
+			"1234567",
+			"1234567",
+			"http://domain.com",
+			rfcCodeVerifier,
+		)
+		assert.NoError(t, err)
+		assert.NotNil(t, tokenResponse)
+		assert.Equal(t, "zzz0eXAiOiJKV1QiLCJhbGciOiJIUzI1NiJ9", tokenResponse.AccessToken)
+		assert.Equal(t, PermissionsIdentity, tokenResponse.Scope)
+	})
+}
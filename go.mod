@@ -1,6 +1,6 @@
 module github.com/tonicpow/go-moneybutton
 
-go 1.18
+go 1.21
 
 require (
 	github.com/gojektech/heimdall/v6 v6.1.0
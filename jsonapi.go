@@ -0,0 +1,259 @@
+package moneybutton
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrMissingData is returned when a JSON:API document does not have the expected primary data
+var ErrMissingData = errors.New("missing primary data in JSON:API document")
+
+// Document is a JSON:API top-level document (all MoneyButton REST responses)
+//
+// Specs: https://jsonapi.org/format/1.0/#document-top-level
+type Document struct {
+	Data     json.RawMessage `json:"data,omitempty"`     // Single resource, array of resources or null
+	Errors   []*ErrorObject  `json:"errors,omitempty"`   // Errors (instead of data)
+	Included []*Resource     `json:"included,omitempty"` // Related resources (compound documents)
+	JSONAPI  *jsonAPIVersion `json:"jsonapi,omitempty"`  // JSON:API version
+	Links    Links           `json:"links,omitempty"`    // IE: self, next, prev (pagination)
+	Meta     Meta            `json:"meta,omitempty"`     // Non-standard meta information
+}
+
+// Resource is a JSON:API resource object (attributes are decoded on demand)
+//
+// Specs: https://jsonapi.org/format/1.0/#document-resource-objects
+type Resource struct {
+	Attributes    json.RawMessage          `json:"attributes,omitempty"`
+	ID            string                   `json:"id"`
+	Links         Links                    `json:"links,omitempty"`
+	Meta          Meta                     `json:"meta,omitempty"`
+	Relationships map[string]*Relationship `json:"relationships,omitempty"`
+	Type          string                   `json:"type"`
+}
+
+// ResourceObject is a JSON:API resource object with typed attributes
+type ResourceObject[A any] struct {
+	Attributes    *A                       `json:"attributes"`
+	ID            string                   `json:"id"`
+	Links         Links                    `json:"links,omitempty"`
+	Meta          Meta                     `json:"meta,omitempty"`
+	Relationships map[string]*Relationship `json:"relationships,omitempty"`
+	Type          string                   `json:"type"`
+}
+
+// ResourceIdentifier identifies a resource (used in relationships)
+type ResourceIdentifier struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// Relationship is a JSON:API relationship (to-one or to-many)
+//
+// Specs: https://jsonapi.org/format/1.0/#document-resource-object-relationships
+type Relationship struct {
+	Data  json.RawMessage `json:"data,omitempty"` // Identifier, array of identifiers or null
+	Links Links           `json:"links,omitempty"`
+	Meta  Meta            `json:"meta,omitempty"`
+}
+
+// Link is a JSON:API link (either a URL string or an object with href and meta)
+type Link struct {
+	Href string `json:"href"`
+	Meta Meta   `json:"meta,omitempty"`
+}
+
+// Links is a set of JSON:API links (IE: self, related, next)
+type Links map[string]*Link
+
+// Meta is non-standard JSON:API meta information
+type Meta map[string]interface{}
+
+// DecodeDocument will decode a JSON:API document
+func DecodeDocument(data []byte) (*Document, error) {
+	doc := new(Document)
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Resource will return the primary data as a single resource (ErrMissingData if empty)
+func (d *Document) Resource() (*Resource, error) {
+	data := bytes.TrimSpace(d.Data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, ErrMissingData
+	} else if data[0] == '[' {
+		return nil, fmt.Errorf("expected a single resource but found a collection")
+	}
+
+	resource := new(Resource)
+	if err := json.Unmarshal(data, &resource); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// Resources will return the primary data as a collection (a single resource is returned as one item)
+func (d *Document) Resources() ([]*Resource, error) {
+	data := bytes.TrimSpace(d.Data)
+	if len(data) == 0 {
+		return nil, ErrMissingData
+	} else if bytes.Equal(data, []byte("null")) {
+		return []*Resource{}, nil
+	} else if data[0] != '[' {
+		resource, err := d.Resource()
+		if err != nil {
+			return nil, err
+		}
+		return []*Resource{resource}, nil
+	}
+
+	var resources []*Resource
+	if err := json.Unmarshal(data, &resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// Find will return the included resource by type and ID (nil if not included)
+func (d *Document) Find(resourceType, id string) *Resource {
+	for _, resource := range d.Included {
+		if resource != nil && resource.Type == resourceType && resource.ID == id {
+			return resource
+		}
+	}
+	return nil
+}
+
+// Related will resolve the named relationship of the resource against the included resources
+//
+// Identifiers that are not included in the document are skipped
+func (d *Document) Related(resource *Resource, name string) ([]*Resource, error) {
+	if resource == nil {
+		return nil, nil
+	}
+	relationship, ok := resource.Relationships[name]
+	if !ok || relationship == nil {
+		return nil, nil
+	}
+
+	identifiers, err := relationship.Identifiers()
+	if err != nil {
+		return nil, err
+	}
+
+	related := make([]*Resource, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		if included := d.Find(identifier.Type, identifier.ID); included != nil {
+			related = append(related, included)
+		}
+	}
+	return related, nil
+}
+
+// Decode will decode the attributes into v
+func (r *Resource) Decode(v interface{}) error {
+	if len(r.Attributes) == 0 {
+		return nil
+	}
+	return json.Unmarshal(r.Attributes, v)
+}
+
+// Identifiers will return the resource identifiers of the relationship (empty for null)
+func (r *Relationship) Identifiers() ([]*ResourceIdentifier, error) {
+	data := bytes.TrimSpace(r.Data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	// To-one relationship
+	if data[0] != '[' {
+		identifier := new(ResourceIdentifier)
+		if err := json.Unmarshal(data, &identifier); err != nil {
+			return nil, err
+		}
+		return []*ResourceIdentifier{identifier}, nil
+	}
+
+	// To-many relationship
+	var identifiers []*ResourceIdentifier
+	if err := json.Unmarshal(data, &identifiers); err != nil {
+		return nil, err
+	}
+	return identifiers, nil
+}
+
+// UnmarshalJSON will decode a link from either a URL string or a link object
+func (l *Link) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &l.Href)
+	}
+
+	// Avoid recursion
+	type linkObject Link
+	return json.Unmarshal(data, (*linkObject)(l))
+}
+
+// Href will return the URL of the named link (empty if not found)
+func (l Links) Href(name string) string {
+	if link, ok := l[name]; ok && link != nil {
+		return link.Href
+	}
+	return ""
+}
+
+// DecodeResource will decode the attributes of the resource into a typed resource object
+func DecodeResource[A any](resource *Resource) (*ResourceObject[A], error) {
+	if resource == nil {
+		return nil, ErrMissingData
+	}
+
+	object := &ResourceObject[A]{
+		ID:            resource.ID,
+		Links:         resource.Links,
+		Meta:          resource.Meta,
+		Relationships: resource.Relationships,
+		Type:          resource.Type,
+	}
+	if len(resource.Attributes) > 0 && !bytes.Equal(bytes.TrimSpace(resource.Attributes), []byte("null")) {
+		object.Attributes = new(A)
+		if err := resource.Decode(object.Attributes); err != nil {
+			return nil, err
+		}
+	}
+	return object, nil
+}
+
+// DecodeResources will decode the attributes of every resource into typed resource objects
+func DecodeResources[A any](resources []*Resource) ([]*ResourceObject[A], error) {
+	objects := make([]*ResourceObject[A], 0, len(resources))
+	for _, resource := range resources {
+		object, err := DecodeResource[A](resource)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// decodeSingle will decode a document with a single (typed) resource as the primary data
+func decodeSingle[A any](data []byte) (*Document, *ResourceObject[A], error) {
+	doc, err := DecodeDocument(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var resource *Resource
+	if resource, err = doc.Resource(); err != nil {
+		return nil, nil, err
+	}
+	var object *ResourceObject[A]
+	if object, err = DecodeResource[A](resource); err != nil {
+		return nil, nil, err
+	}
+	return doc, object, nil
+}
@@ -0,0 +1,177 @@
+package moneybutton
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCompoundDocument is a collection with relationships and included resources
+const testCompoundDocument = `{
+  "data": [
+    {
+      "type": "payments",
+      "id": "1",
+      "attributes": {"status": "COMPLETED"},
+      "links": {"self": {"href": "https://www.moneybutton.com/api/v1/payments/1", "meta": {"count": 1}}},
+      "relationships": {
+        "user": {"data": {"type": "users", "id": "123"}},
+        "payment-outputs": {"data": [{"type": "payment-outputs", "id": "7"}, {"type": "payment-outputs", "id": "99"}]},
+        "button": {"data": null, "links": {"related": "https://www.moneybutton.com/api/v1/buttons/1"}}
+      }
+    },
+    {"type": "payments", "id": "2", "attributes": null}
+  ],
+  "included": [
+    {"type": "users", "id": "123", "attributes": {"name": "MrZ"}},
+    {"type": "payment-outputs", "id": "7", "attributes": {"to": "mrz@moneybutton.com"}}
+  ],
+  "jsonapi": {"version": "1.0"},
+  "links": {"self": "https://www.moneybutton.com/api/v1/payments", "next": null},
+  "meta": {"total-count": 2}
+}`
+
+// TestDecodeDocument tests the method DecodeDocument()
+func TestDecodeDocument(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid json", func(t *testing.T) {
+		doc, err := DecodeDocument([]byte(`{`))
+		assert.Error(t, err)
+		assert.Nil(t, doc)
+	})
+
+	t.Run("compound document", func(t *testing.T) {
+		doc, err := DecodeDocument([]byte(testCompoundDocument))
+		require.NoError(t, err)
+		require.NotNil(t, doc.JSONAPI)
+		assert.Equal(t, "1.0", doc.JSONAPI.Version)
+		assert.Equal(t, "https://www.moneybutton.com/api/v1/payments", doc.Links.Href("self"))
+		assert.Equal(t, "", doc.Links.Href("next"))
+		assert.Equal(t, "", doc.Links.Href("unknown"))
+		assert.Equal(t, float64(2), doc.Meta["total-count"])
+		assert.Len(t, doc.Included, 2)
+
+		// A collection is not a single resource
+		resource, err := doc.Resource()
+		assert.Error(t, err)
+		assert.Nil(t, resource)
+
+		resources, err := doc.Resources()
+		require.NoError(t, err)
+		require.Len(t, resources, 2)
+
+		// Link objects
+		assert.Equal(t, "https://www.moneybutton.com/api/v1/payments/1", resources[0].Links.Href("self"))
+		assert.Equal(t, float64(1), resources[0].Links["self"].Meta["count"])
+
+		// To-one relationship
+		users, err := doc.Related(resources[0], "user")
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, "123", users[0].ID)
+
+		// To-many relationship (identifiers that are not included are skipped)
+		outputs, err := doc.Related(resources[0], "payment-outputs")
+		require.NoError(t, err)
+		require.Len(t, outputs, 1)
+		assert.Equal(t, "7", outputs[0].ID)
+
+		// Null relationship
+		buttons, err := doc.Related(resources[0], "button")
+		require.NoError(t, err)
+		assert.Len(t, buttons, 0)
+		assert.Equal(t, "https://www.moneybutton.com/api/v1/buttons/1", resources[0].Relationships["button"].Links.Href("related"))
+
+		// Unknown relationship
+		unknown, err := doc.Related(resources[1], "user")
+		require.NoError(t, err)
+		assert.Len(t, unknown, 0)
+
+		assert.NotNil(t, doc.Find("users", "123"))
+		assert.Nil(t, doc.Find("users", "456"))
+	})
+
+	t.Run("null data", func(t *testing.T) {
+		doc, err := DecodeDocument([]byte(`{"data":null}`))
+		require.NoError(t, err)
+
+		resource, err := doc.Resource()
+		assert.ErrorIs(t, err, ErrMissingData)
+		assert.Nil(t, resource)
+
+		resources, err := doc.Resources()
+		require.NoError(t, err)
+		assert.Len(t, resources, 0)
+	})
+
+	t.Run("missing data", func(t *testing.T) {
+		doc, err := DecodeDocument([]byte(`{"meta":{}}`))
+		require.NoError(t, err)
+
+		_, err = doc.Resource()
+		assert.ErrorIs(t, err, ErrMissingData)
+		_, err = doc.Resources()
+		assert.ErrorIs(t, err, ErrMissingData)
+	})
+
+	t.Run("single resource as a collection", func(t *testing.T) {
+		doc, err := DecodeDocument([]byte(`{"data":{"type":"users","id":"123"}}`))
+		require.NoError(t, err)
+
+		resources, err := doc.Resources()
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+		assert.Equal(t, "123", resources[0].ID)
+	})
+}
+
+// TestDecodeResource tests the methods DecodeResource() and DecodeResources()
+func TestDecodeResource(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing resource", func(t *testing.T) {
+		object, err := DecodeResource[Identity](nil)
+		assert.ErrorIs(t, err, ErrMissingData)
+		assert.Nil(t, object)
+	})
+
+	t.Run("typed attributes", func(t *testing.T) {
+		doc, err := DecodeDocument([]byte(testCompoundDocument))
+		require.NoError(t, err)
+		resources, err := doc.Resources()
+		require.NoError(t, err)
+
+		objects, err := DecodeResources[PaymentAttributes](resources)
+		require.NoError(t, err)
+		require.Len(t, objects, 2)
+		assert.Equal(t, "payments", objects[0].Type)
+		require.NotNil(t, objects[0].Attributes)
+		assert.Equal(t, PaymentStatusCompleted, objects[0].Attributes.Status)
+		assert.Contains(t, objects[0].Relationships, "user")
+
+		// Null attributes
+		assert.Nil(t, objects[1].Attributes)
+	})
+
+	t.Run("invalid attributes", func(t *testing.T) {
+		objects, err := DecodeResources[PaymentAttributes]([]*Resource{
+			{ID: "1", Type: "payments", Attributes: []byte(`{"status":1}`)},
+		})
+		assert.Error(t, err)
+		assert.Nil(t, objects)
+	})
+
+	t.Run("decode single", func(t *testing.T) {
+		doc, object, err := decodeSingle[Identity]([]byte(`{"data":{"type":"user_identities","id":"123","attributes":{"id":"123","name":"MrZ"}},"jsonapi":{"version":"1.0"}}`))
+		require.NoError(t, err)
+		assert.Equal(t, "1.0", doc.JSONAPI.Version)
+		assert.Equal(t, "MrZ", object.Attributes.Name)
+
+		_, _, err = decodeSingle[Identity]([]byte(`{"data":[]}`))
+		assert.Error(t, err)
+		_, _, err = decodeSingle[Identity]([]byte(`{`))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,252 @@
+package moneybutton
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// PaymentSort is the sort order for listing payments
+type PaymentSort string
+
+// Sort orders for listing payments
+const (
+	SortCreatedAtAsc  PaymentSort = "created-at"
+	SortCreatedAtDesc PaymentSort = "-created-at"
+)
+
+// ListPaymentsOptions are the filters, sort order and pagination for listing payments
+//
+// All fields are optional
+type ListPaymentsOptions struct {
+	ButtonID      string        `json:"button_id"`      // Only payments for the button ID
+	CreatedAfter  time.Time     `json:"created_after"`  // Only payments created on or after
+	CreatedBefore time.Time     `json:"created_before"` // Only payments created before
+	OwnerID       string        `json:"owner_id"`       // Only payments owned by the user ID
+	PageNumber    int           `json:"page_number"`    // Page to return (default is 1)
+	PageSize      int           `json:"page_size"`      // Payments per page (default is 20, max is 100)
+	Sort          PaymentSort   `json:"sort"`           // Sort order (default is the API default)
+	Status        PaymentStatus `json:"status"`         // Only payments with the status (IE: PaymentStatusCompleted)
+}
+
+/*
+{
+  "data": [
+    {
+      "type": "payments",
+      "id": "1040",
+      "attributes": {...}
+    }
+  ],
+  "links": {
+    "self": "https://www.moneybutton.com/api/v1/payments?page[number]=1&page[size]=20",
+    "next": "https://www.moneybutton.com/api/v1/payments?page[number]=2&page[size]=20"
+  },
+  "meta": {
+    "total-count": 42
+  }
+}
+*/
+
+// ListPayments returns a single page of payments (use PaymentIterator() to walk every page)
+//
+// Specs: https://docs.moneybutton.com/docs/api-rest-payments.html
+func (c *Client) ListPayments(ctx context.Context, accessToken string,
+	options *ListPaymentsOptions) (*Payments, error) {
+
+	// Check required parameters
+	if len(accessToken) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "accessToken")
+	}
+
+	// Build the query
+	query, err := listPaymentsQuery(options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fire the request
+	response := httpRequest(
+		ctx,
+		c,
+		&httpPayload{
+			Endpoint:       "ListPayments",
+			ExpectedStatus: http.StatusOK,
+			Method:         http.MethodGet,
+			Token:          accessToken,
+			URL:            c.apiURL(endpointPayments) + "?" + query.Encode(),
+		},
+	)
+
+	// Error in request?
+	if response.Error != nil {
+		return nil, response.Error
+	}
+
+	// Create the response
+	var doc *Document
+	if doc, err = DecodeDocument(response.BodyContents); err != nil {
+		return nil, err
+	}
+	var resources []*Resource
+	if resources, err = doc.Resources(); err != nil {
+		return nil, err
+	}
+	payments := &Payments{
+		Data:    make([]*PaymentData, 0, len(resources)),
+		JSONAPI: doc.JSONAPI,
+		Links:   doc.Links,
+		Meta:    doc.Meta,
+	}
+	for _, resource := range resources {
+		var payment *PaymentData
+		if payment, err = paymentFromResource(doc, resource); err != nil {
+			return nil, err
+		}
+		payments.Data = append(payments.Data, payment)
+	}
+	return payments, nil
+}
+
+// listPaymentsQuery will validate the options and build the query
+func listPaymentsQuery(options *ListPaymentsOptions) (url.Values, error) {
+	if options == nil {
+		options = new(ListPaymentsOptions)
+	}
+
+	// Check the pagination
+	pageNumber, pageSize := options.PageNumber, options.PageSize
+	if pageNumber < 0 {
+		return nil, fmt.Errorf("invalid parameter: %s", "PageNumber")
+	} else if pageSize < 0 || pageSize > maxPageSize {
+		return nil, fmt.Errorf("invalid parameter: %s (max is %d)", "PageSize", maxPageSize)
+	}
+	if pageNumber == 0 {
+		pageNumber = 1
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	// Check the date range
+	if !options.CreatedAfter.IsZero() && !options.CreatedBefore.IsZero() &&
+		!options.CreatedAfter.Before(options.CreatedBefore) {
+		return nil, fmt.Errorf("invalid parameter: %s must be before %s", "CreatedAfter", "CreatedBefore")
+	}
+
+	// Build the query
+	query := url.Values{}
+	query.Set("page[number]", strconv.Itoa(pageNumber))
+	query.Set("page[size]", strconv.Itoa(pageSize))
+	if len(options.ButtonID) > 0 {
+		query.Set("filter[button-id]", options.ButtonID)
+	}
+	if !options.CreatedAfter.IsZero() {
+		query.Set("filter[created-after]", options.CreatedAfter.UTC().Format(time.RFC3339))
+	}
+	if !options.CreatedBefore.IsZero() {
+		query.Set("filter[created-before]", options.CreatedBefore.UTC().Format(time.RFC3339))
+	}
+	if len(options.OwnerID) > 0 {
+		query.Set("filter[owner-id]", options.OwnerID)
+	}
+	if len(options.Status) > 0 {
+		query.Set("filter[status]", string(options.Status))
+	}
+	if len(options.Sort) > 0 {
+		query.Set("sort", string(options.Sort))
+	}
+	return query, nil
+}
+
+// PaymentIterator lazily walks every page of payments (one request per page)
+//
+//	it := client.PaymentIterator(accessToken, options)
+//	for it.Next(ctx) {
+//		payment := it.Payment()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type PaymentIterator struct {
+	accessToken string
+	client      *Client
+	done        bool
+	err         error
+	index       int
+	options     ListPaymentsOptions
+	page        []*PaymentData
+}
+
+// PaymentIterator will return an iterator over every payment matching the options
+//
+// Iteration starts on options.PageNumber (default is the first page)
+func (c *Client) PaymentIterator(accessToken string, options *ListPaymentsOptions) *PaymentIterator {
+	it := &PaymentIterator{accessToken: accessToken, client: c}
+	if options != nil {
+		it.options = *options
+	}
+	if it.options.PageNumber == 0 {
+		it.options.PageNumber = 1
+	}
+	if it.options.PageSize == 0 {
+		it.options.PageSize = defaultPageSize
+	}
+	return it
+}
+
+// Next will advance to the next payment (fetching the next page if needed)
+//
+// Returns false when there are no more payments or an error occurred (see: Err)
+func (it *PaymentIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	// Advance in the current page
+	if it.index+1 < len(it.page) {
+		it.index++
+		return true
+	}
+
+	// Fetch the next page
+	for !it.done {
+		payments, err := it.client.ListPayments(ctx, it.accessToken, &it.options)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		// Last page: there is no next link, or (without links) the page was not full
+		if payments.Links != nil {
+			it.done = len(payments.Links.Href("next")) == 0
+		} else {
+			it.done = len(payments.Data) < it.options.PageSize
+		}
+		it.options.PageNumber++
+
+		if len(payments.Data) > 0 {
+			it.page = payments.Data
+			it.index = 0
+			return true
+		}
+		it.done = true
+	}
+	return false
+}
+
+// Payment will return the current payment
+func (it *PaymentIterator) Payment() *PaymentData {
+	if it.index < len(it.page) {
+		return it.page[it.index]
+	}
+	return nil
+}
+
+// Err will return the error that stopped the iteration (if any)
+func (it *PaymentIterator) Err() error {
+	return it.err
+}
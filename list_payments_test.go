@@ -0,0 +1,229 @@
+package moneybutton
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHTTPListPayments for mocking requests (serves total payments in pages)
+type mockHTTPListPayments struct {
+	failOnPage int
+	noLinks    bool
+	requests   []string
+	total      int
+}
+
+// Do is a mock http request
+func (m *mockHTTPListPayments) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+	resp.StatusCode = http.StatusBadRequest
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	m.requests = append(m.requests, req.URL.RawQuery)
+	query := req.URL.Query()
+	pageNumber, _ := strconv.Atoi(query.Get("page[number]"))
+	pageSize, _ := strconv.Atoi(query.Get("page[size]"))
+
+	if pageNumber == m.failOnPage {
+		resp.StatusCode = http.StatusInternalServerError
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(``)))
+		return resp, nil
+	}
+
+	if !strings.HasPrefix(req.URL.String(), APIURL+endpointPayments+"?") {
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(``)))
+		return resp, nil
+	}
+
+	// Build the page
+	var data []string
+	for i := (pageNumber-1)*pageSize + 1; i <= pageNumber*pageSize && i <= m.total; i++ {
+		data = append(data, fmt.Sprintf(`{"type":"payments","id":"%d","attributes":{"status":"COMPLETED","amount":"0.01","currency":"USD"}}`, i))
+	}
+	body := `{"data":[` + strings.Join(data, ",") + `]`
+	if !m.noLinks {
+		body += `,"links":{"self":"self"`
+		if pageNumber*pageSize < m.total {
+			body += `,"next":"next"`
+		}
+		body += `}`
+	}
+	body += fmt.Sprintf(`,"meta":{"total-count":%d}}`, m.total)
+
+	resp.StatusCode = http.StatusOK
+	resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(body)))
+
+	// Default is valid
+	return resp, nil
+}
+
+func TestClient_ListPayments(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing access token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPListPayments{total: 5})
+		payments, err := client.ListPayments(context.Background(), "", nil)
+		assert.Error(t, err)
+		assert.Nil(t, payments)
+	})
+
+	t.Run("invalid page number", func(t *testing.T) {
+		client := newTestClient(&mockHTTPListPayments{total: 5})
+		payments, err := client.ListPayments(context.Background(), "1234567", &ListPaymentsOptions{PageNumber: -1})
+		assert.Error(t, err)
+		assert.Nil(t, payments)
+	})
+
+	t.Run("invalid page size", func(t *testing.T) {
+		client := newTestClient(&mockHTTPListPayments{total: 5})
+		payments, err := client.ListPayments(context.Background(), "1234567", &ListPaymentsOptions{PageSize: maxPageSize + 1})
+		assert.Error(t, err)
+		assert.Nil(t, payments)
+	})
+
+	t.Run("invalid date range", func(t *testing.T) {
+		client := newTestClient(&mockHTTPListPayments{total: 5})
+		now := time.Now()
+		payments, err := client.ListPayments(context.Background(), "1234567", &ListPaymentsOptions{
+			CreatedAfter: now, CreatedBefore: now.Add(-time.Hour),
+		})
+		assert.Error(t, err)
+		assert.Nil(t, payments)
+	})
+
+	t.Run("http error", func(t *testing.T) {
+		client := newTestClient(&mockHTTPError{})
+		payments, err := client.ListPayments(context.Background(), "1234567", nil)
+		assert.Error(t, err)
+		assert.Nil(t, payments)
+	})
+
+	t.Run("default options", func(t *testing.T) {
+		mock := &mockHTTPListPayments{total: 5}
+		client := newTestClient(mock)
+		payments, err := client.ListPayments(context.Background(), "1234567", nil)
+		require.NoError(t, err)
+		require.Len(t, payments.Data, 5)
+		assert.Equal(t, "1", payments.Data[0].ID)
+		assert.Equal(t, PaymentStatusCompleted, payments.Data[0].Attributes.Status)
+		assert.Equal(t, "self", payments.Links.Href("self"))
+		assert.Equal(t, "", payments.Links.Href("next"))
+		assert.Equal(t, float64(5), payments.Meta["total-count"])
+		assert.Equal(t, []string{"page%5Bnumber%5D=1&page%5Bsize%5D=20"}, mock.requests)
+	})
+
+	t.Run("filters and sort", func(t *testing.T) {
+		mock := &mockHTTPListPayments{total: 5}
+		client := newTestClient(mock)
+		_, err := client.ListPayments(context.Background(), "1234567", &ListPaymentsOptions{
+			ButtonID:      "order 123",
+			CreatedAfter:  time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			CreatedBefore: time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC),
+			OwnerID:       "123",
+			PageNumber:    2,
+			PageSize:      10,
+			Sort:          SortCreatedAtDesc,
+			Status:        PaymentStatusCompleted,
+		})
+		require.NoError(t, err)
+		require.Len(t, mock.requests, 1)
+
+		query, err := url.ParseQuery(mock.requests[0])
+		require.NoError(t, err)
+		assert.Equal(t, "order 123", query.Get("filter[button-id]"))
+		assert.Equal(t, "2021-01-01T00:00:00Z", query.Get("filter[created-after]"))
+		assert.Equal(t, "2021-02-01T00:00:00Z", query.Get("filter[created-before]"))
+		assert.Equal(t, "123", query.Get("filter[owner-id]"))
+		assert.Equal(t, "COMPLETED", query.Get("filter[status]"))
+		assert.Equal(t, "2", query.Get("page[number]"))
+		assert.Equal(t, "10", query.Get("page[size]"))
+		assert.Equal(t, "-created-at", query.Get("sort"))
+	})
+}
+
+func TestClient_PaymentIterator(t *testing.T) {
+	t.Parallel()
+
+	t.Run("walks every page", func(t *testing.T) {
+		mock := &mockHTTPListPayments{total: 45}
+		client := newTestClient(mock)
+		it := client.PaymentIterator("1234567", &ListPaymentsOptions{PageSize: 10})
+		assert.Nil(t, it.Payment())
+
+		var ids []string
+		for it.Next(context.Background()) {
+			ids = append(ids, it.Payment().ID)
+		}
+		require.NoError(t, it.Err())
+		require.Len(t, ids, 45)
+		assert.Equal(t, "1", ids[0])
+		assert.Equal(t, "45", ids[44])
+		assert.Len(t, mock.requests, 5)
+
+		// Done
+		assert.False(t, it.Next(context.Background()))
+		assert.Len(t, mock.requests, 5)
+	})
+
+	t.Run("full last page without links", func(t *testing.T) {
+		mock := &mockHTTPListPayments{total: 20, noLinks: true}
+		client := newTestClient(mock)
+		it := client.PaymentIterator("1234567", &ListPaymentsOptions{PageSize: 10})
+
+		count := 0
+		for it.Next(context.Background()) {
+			count++
+		}
+		require.NoError(t, it.Err())
+		assert.Equal(t, 20, count)
+		assert.Len(t, mock.requests, 3)
+	})
+
+	t.Run("no payments", func(t *testing.T) {
+		mock := &mockHTTPListPayments{total: 0}
+		client := newTestClient(mock)
+		it := client.PaymentIterator("1234567", nil)
+		assert.False(t, it.Next(context.Background()))
+		assert.NoError(t, it.Err())
+		assert.Nil(t, it.Payment())
+		assert.Len(t, mock.requests, 1)
+	})
+
+	t.Run("lazy", func(t *testing.T) {
+		mock := &mockHTTPListPayments{total: 45}
+		client := newTestClient(mock)
+		it := client.PaymentIterator("1234567", &ListPaymentsOptions{PageSize: 10})
+		assert.Len(t, mock.requests, 0)
+		require.True(t, it.Next(context.Background()))
+		assert.Len(t, mock.requests, 1)
+	})
+
+	t.Run("error on a page", func(t *testing.T) {
+		mock := &mockHTTPListPayments{total: 45, failOnPage: 2}
+		client := newTestClient(mock)
+		it := client.PaymentIterator("1234567", &ListPaymentsOptions{PageSize: 10})
+
+		count := 0
+		for it.Next(context.Background()) {
+			count++
+		}
+		assert.Error(t, it.Err())
+		assert.Equal(t, 10, count)
+		assert.False(t, it.Next(context.Background()))
+	})
+}
@@ -0,0 +1,86 @@
+package moneybutton
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+// logMessage is the message of every request log record
+const logMessage = "moneybutton request"
+
+// attemptsKey is the context key for counting the attempts of a request (including retries)
+type attemptsKey struct{}
+
+// withAttemptCounter will add an attempt counter to the context (incremented by the attemptCounter plugin)
+func withAttemptCounter(ctx context.Context) (context.Context, *int32) {
+	attempts := new(int32)
+	return context.WithValue(ctx, attemptsKey{}, attempts), attempts
+}
+
+// attemptCounter is a heimdall plugin that counts every attempt of a request (heimdall does the retries)
+type attemptCounter struct{}
+
+// OnRequestStart will count the attempt
+func (attemptCounter) OnRequestStart(req *http.Request) {
+	if attempts, ok := req.Context().Value(attemptsKey{}).(*int32); ok {
+		atomic.AddInt32(attempts, 1)
+	}
+}
+
+// OnRequestEnd is not used
+func (attemptCounter) OnRequestEnd(*http.Request, *http.Response) {}
+
+// OnError is not used
+func (attemptCounter) OnError(*http.Request, error) {}
+
+// logRequest will log the request at debug level on success, warn on a client error (4xx or canceled)
+// and error on a server error (5xx) or a transport error
+func logRequest(ctx context.Context, logger *slog.Logger, info *ResponseInfo) {
+	if logger == nil {
+		return
+	}
+
+	// Pick the level
+	level := slog.LevelDebug
+	if info.Response.Error != nil {
+		level = logLevel(info)
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("endpoint", info.Endpoint),
+		slog.String("method", info.Method),
+		slog.String("url", info.URL),
+		slog.Int("status", info.StatusCode),
+		slog.Duration("latency", info.Duration),
+		slog.Int("attempts", info.Attempts),
+	}
+	if err := info.Response.Error; err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			if ids := apiErr.ErrorIDs(); len(ids) > 0 {
+				attrs = append(attrs, slog.Any("error_ids", ids))
+			}
+		}
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	logger.LogAttrs(ctx, level, logMessage, attrs...)
+}
+
+// logLevel will return the level of a failed request
+func logLevel(info *ResponseInfo) slog.Level {
+	switch {
+	case info.StatusCode >= http.StatusInternalServerError:
+		return slog.LevelError
+	case info.StatusCode > 0,
+		errors.Is(info.Response.Error, context.Canceled),
+		info.Attempts == 0:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
@@ -0,0 +1,198 @@
+package moneybutton
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLogger is a logger that captures the JSON records
+type testLogger struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+// Write will capture the records (the handler can be used concurrently)
+func (l *testLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+// records will return the captured records
+func (l *testLogger) records(t *testing.T) (records []map[string]interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimSpace(l.buf.String()), "\n") {
+		if len(line) > 0 {
+			record := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			records = append(records, record)
+		}
+	}
+	return
+}
+
+// newLoggingClient will return a client logging to a testLogger at the given level
+func newLoggingClient(options *ClientOptions, level slog.Level, customHTTPClient *http.Client,
+	environment *Environment) (*Client, *testLogger) {
+
+	logger := &testLogger{}
+	if options == nil {
+		options = DefaultClientOptions()
+	}
+	options.Logger = slog.New(slog.NewJSONHandler(logger, &slog.HandlerOptions{Level: level}))
+	return NewClient(options, customHTTPClient, environment), logger
+}
+
+// TestClient_Logger tests the request logging of the Client
+func TestClient_Logger(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success at debug level", func(t *testing.T) {
+		client, logger := newLoggingClient(nil, slog.LevelDebug, nil, nil)
+		client.SetHTTPClient(&mockHTTPGetUserIdentity{})
+		_, err := client.GetUserIdentity(context.Background(), testSecretAccessToken)
+		require.NoError(t, err)
+
+		records := logger.records(t)
+		require.Len(t, records, 1)
+		assert.Equal(t, "DEBUG", records[0]["level"])
+		assert.Equal(t, logMessage, records[0]["msg"])
+		assert.Equal(t, "GetUserIdentity", records[0]["endpoint"])
+		assert.Equal(t, http.MethodGet, records[0]["method"])
+		assert.Equal(t, APIURL+endpointUserIdentity, records[0]["url"])
+		assert.Equal(t, float64(http.StatusOK), records[0]["status"])
+		assert.Equal(t, float64(1), records[0]["attempts"])
+		assert.Contains(t, records[0], "latency")
+		assert.NotContains(t, records[0], "error")
+	})
+
+	t.Run("success is not logged at info level", func(t *testing.T) {
+		client, logger := newLoggingClient(nil, slog.LevelInfo, nil, nil)
+		client.SetHTTPClient(&mockHTTPGetUserIdentity{})
+		_, err := client.GetUserIdentity(context.Background(), testSecretAccessToken)
+		require.NoError(t, err)
+		assert.Empty(t, logger.records(t))
+	})
+
+	t.Run("api error at warn level", func(t *testing.T) {
+		client, logger := newLoggingClient(nil, slog.LevelInfo, nil, nil)
+		client.SetHTTPClient(&mockHTTPAPIError{})
+		_, err := client.GetRefreshToken(context.Background(), "client-id", "auth-code-1234567890", "https://example.com")
+		require.Error(t, err)
+
+		records := logger.records(t)
+		require.Len(t, records, 1)
+		assert.Equal(t, "WARN", records[0]["level"])
+		assert.Equal(t, "GetRefreshToken", records[0]["endpoint"])
+		assert.Equal(t, float64(http.StatusBadRequest), records[0]["status"])
+		assert.Equal(t, []interface{}{"ffb71830-409b-11eb-9032-37efc953c879"}, records[0]["error_ids"])
+		assert.Contains(t, records[0]["error"], "authorization code has expired")
+	})
+
+	t.Run("server error with retries at error level", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		client, logger := newLoggingClient(nil, slog.LevelInfo, nil, &Environment{APIURL: server.URL})
+		_, err := client.GetUserIdentity(context.Background(), testSecretAccessToken)
+		require.Error(t, err)
+
+		records := logger.records(t)
+		require.Len(t, records, 1)
+		assert.Equal(t, "ERROR", records[0]["level"])
+		assert.Equal(t, float64(http.StatusInternalServerError), records[0]["status"])
+		assert.Equal(t, float64(DefaultClientOptions().RequestRetryCount+1), records[0]["attempts"])
+	})
+
+	t.Run("transport error at error level (redacted)", func(t *testing.T) {
+		options := DefaultClientOptions()
+		options.RequestRetryCount = 0
+		client, logger := newLoggingClient(options, slog.LevelInfo, nil, &Environment{
+			APIURL: "http://127.0.0.1:1/?access_token=" + testSecretAccessToken + "&",
+		})
+		_, err := client.GetUserIdentity(context.Background(), testSecretAccessToken)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), testSecretAccessToken)
+
+		records := logger.records(t)
+		require.Len(t, records, 1)
+		assert.Equal(t, "ERROR", records[0]["level"])
+		assert.Equal(t, float64(0), records[0]["status"])
+		assert.Equal(t, float64(1), records[0]["attempts"])
+		assert.NotContains(t, logger.buf.String(), testSecretAccessToken)
+	})
+
+	t.Run("canceled request at warn level", func(t *testing.T) {
+		options := DefaultClientOptions()
+		options.BeforeRequest = []BeforeRequestHook{func(*http.Request) error { return errors.New("canceled") }}
+		client, logger := newLoggingClient(options, slog.LevelInfo, nil, nil)
+		_, err := client.GetUserIdentity(context.Background(), testSecretAccessToken)
+		require.Error(t, err)
+
+		records := logger.records(t)
+		require.Len(t, records, 1)
+		assert.Equal(t, "WARN", records[0]["level"])
+		assert.Equal(t, float64(0), records[0]["attempts"])
+		assert.Equal(t, "canceled", records[0]["error"])
+	})
+
+	t.Run("token request is redacted", func(t *testing.T) {
+		client, logger := newLoggingClient(nil, slog.LevelDebug, nil, nil)
+		client.SetHTTPClient(&mockHTTPEchoSecrets{})
+		_, err := client.RefreshAccessToken(context.Background(), "client-id", testSecretRefreshToken)
+		require.Error(t, err)
+		assert.Contains(t, logger.buf.String(), Redacted)
+		assert.NotContains(t, logger.buf.String(), testSecretRefreshToken)
+	})
+}
+
+// TestRedactError tests the method redactError()
+func TestRedactError(t *testing.T) {
+	t.Parallel()
+
+	errCause := fmt.Errorf("Get \"http://localhost/?access_token=%s\": connection refused", testSecretAccessToken)
+	err := redactError(errCause, []string{testSecretAccessToken})
+	assert.NotContains(t, err.Error(), testSecretAccessToken)
+	assert.ErrorIs(t, err, errCause)
+
+	// Nothing to redact
+	assert.Equal(t, errCause, redactError(errCause, []string{"other-secret"}))
+}
+
+// ExampleClientOptions_logger example using ClientOptions.Logger
+func ExampleClientOptions_logger() {
+	options := DefaultClientOptions()
+	options.Logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.TimeKey || attr.Key == "latency" {
+				return slog.Attr{} // Remove the values that change on every run
+			}
+			return attr
+		},
+	}))
+
+	client := NewClient(options, nil, nil)
+	client.SetHTTPClient(&mockHTTPGetUserIdentity{})
+	if _, err := client.GetUserIdentity(context.Background(), "access-token"); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	// Output:level=DEBUG msg="moneybutton request" endpoint=GetUserIdentity method=GET url=https://www.moneybutton.com/api/v1/auth/user_identity status=200 attempts=1
+}
@@ -0,0 +1,263 @@
+package moneybutton
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metrics is called by the Client for every request and token refresh (IE: to export Prometheus metrics)
+//
+// Implementations must be safe for concurrent use, see PrometheusMetrics for an in-process implementation
+type Metrics interface {
+	ObserveRequest(info *ResponseInfo) // Called after every request (the info is redacted)
+	ObserveTokenRefresh(err error)     // Called after every RefreshAccessToken request (nil on success)
+}
+
+// NopMetrics is a Metrics that does nothing (the default when ClientOptions.Metrics is not set)
+type NopMetrics struct{}
+
+// ObserveRequest does nothing
+func (NopMetrics) ObserveRequest(*ResponseInfo) {}
+
+// ObserveTokenRefresh does nothing
+func (NopMetrics) ObserveTokenRefresh(error) {}
+
+// DefaultLatencyBuckets are the upper bounds (in seconds) of the request latency histogram
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metric names of PrometheusMetrics
+const (
+	metricRequestDuration = "moneybutton_request_duration_seconds"
+	metricRequestErrors   = "moneybutton_request_errors_total"
+	metricRequests        = "moneybutton_requests_total"
+	metricTokenRefreshes  = "moneybutton_token_refreshes_total"
+)
+
+// Label values and content type of PrometheusMetrics
+const (
+	prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+	statusClassNoResponse = "none"
+	tokenRefreshFailure   = "failure"
+	tokenRefreshSuccess   = "success"
+)
+
+// PrometheusMetrics is an in-process Metrics that serves the counters and histograms
+// in the Prometheus text exposition format (no external dependencies)
+//
+// Metrics:
+//   - moneybutton_requests_total{endpoint,method}: requests (including failed requests)
+//   - moneybutton_request_errors_total{endpoint,status_class}: failed requests (IE: 4xx, 5xx or none)
+//   - moneybutton_request_duration_seconds{endpoint}: request latency histogram
+//   - moneybutton_token_refreshes_total{result}: access token refreshes (success or failure)
+type PrometheusMetrics struct {
+	buckets        []float64
+	durations      map[string]*histogram // By endpoint
+	errors         map[[2]string]uint64  // By endpoint and status class
+	mu             sync.Mutex
+	requests       map[[2]string]uint64 // By endpoint and method
+	tokenRefreshes map[string]uint64    // By result
+}
+
+// histogram is a Prometheus histogram (cumulative counts are calculated when written)
+type histogram struct {
+	counts []uint64 // Observations per bucket (the last one is +Inf)
+	count  uint64
+	sum    float64
+}
+
+// NewPrometheusMetrics will create a new PrometheusMetrics (nil buckets will use DefaultLatencyBuckets)
+func NewPrometheusMetrics(buckets []float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &PrometheusMetrics{
+		buckets:        sorted,
+		durations:      map[string]*histogram{},
+		errors:         map[[2]string]uint64{},
+		requests:       map[[2]string]uint64{},
+		tokenRefreshes: map[string]uint64{},
+	}
+}
+
+// ObserveRequest will count the request, the error (if any) and the latency
+func (m *PrometheusMetrics) ObserveRequest(info *ResponseInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[[2]string{info.Endpoint, info.Method}]++
+	if info.Response != nil && info.Response.Error != nil {
+		m.errors[[2]string{info.Endpoint, statusClass(info.StatusCode)}]++
+	}
+
+	h, ok := m.durations[info.Endpoint]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets)+1)}
+		m.durations[info.Endpoint] = h
+	}
+	seconds := info.Duration.Seconds()
+	h.counts[sort.SearchFloat64s(m.buckets, seconds)]++
+	h.count++
+	h.sum += seconds
+}
+
+// ObserveTokenRefresh will count the token refresh
+func (m *PrometheusMetrics) ObserveTokenRefresh(err error) {
+	result := tokenRefreshSuccess
+	if err != nil {
+		result = tokenRefreshFailure
+	}
+	m.mu.Lock()
+	m.tokenRefreshes[result]++
+	m.mu.Unlock()
+}
+
+// ServeHTTP will serve the metrics in the Prometheus text exposition format (IE: on /metrics)
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	_, _ = m.WriteTo(w)
+}
+
+// WriteTo will write the metrics in the Prometheus text exposition format
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{w: bufio.NewWriter(w)}
+
+	m.mu.Lock()
+	writeHeader(counter, metricRequests, "counter", "Total requests to the MoneyButton API.")
+	for _, key := range sortedKeys(m.requests) {
+		writeSample(counter, metricRequests, labels("endpoint", key[0], "method", key[1]), formatUint(m.requests[key]))
+	}
+
+	writeHeader(counter, metricRequestErrors, "counter", "Total failed requests to the MoneyButton API.")
+	for _, key := range sortedKeys(m.errors) {
+		writeSample(counter, metricRequestErrors, labels("endpoint", key[0], "status_class", key[1]),
+			formatUint(m.errors[key]))
+	}
+
+	writeHeader(counter, metricRequestDuration, "histogram", "Latency of the requests to the MoneyButton API.")
+	endpoints := make([]string, 0, len(m.durations))
+	for endpoint := range m.durations {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	for _, endpoint := range endpoints {
+		h := m.durations[endpoint]
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += h.counts[i]
+			writeSample(counter, metricRequestDuration+"_bucket",
+				labels("endpoint", endpoint, "le", formatFloat(bound)), formatUint(cumulative))
+		}
+		writeSample(counter, metricRequestDuration+"_bucket", labels("endpoint", endpoint, "le", "+Inf"), formatUint(h.count))
+		writeSample(counter, metricRequestDuration+"_sum", labels("endpoint", endpoint), formatFloat(h.sum))
+		writeSample(counter, metricRequestDuration+"_count", labels("endpoint", endpoint), formatUint(h.count))
+	}
+
+	writeHeader(counter, metricTokenRefreshes, "counter", "Total access token refreshes.")
+	for _, result := range []string{tokenRefreshFailure, tokenRefreshSuccess} {
+		writeSample(counter, metricTokenRefreshes, labels("result", result), formatUint(m.tokenRefreshes[result]))
+	}
+	m.mu.Unlock()
+
+	if counter.err == nil {
+		counter.err = counter.w.Flush()
+	}
+	return counter.n, counter.err
+}
+
+// countingWriter is a buffered writer that keeps the first error and the bytes written
+type countingWriter struct {
+	err error
+	n   int64
+	w   *bufio.Writer
+}
+
+// writeString will write the string (nothing is written after an error)
+func (c *countingWriter) writeString(s string) {
+	if c.err != nil {
+		return
+	}
+	var n int
+	n, c.err = c.w.WriteString(s)
+	c.n += int64(n)
+}
+
+// writeHeader will write the HELP and TYPE lines of a metric
+func writeHeader(w *countingWriter, name, metricType, help string) {
+	w.writeString("# HELP " + name + " " + help + "\n# TYPE " + name + " " + metricType + "\n")
+}
+
+// writeSample will write a sample line
+func writeSample(w *countingWriter, name, labelSet, value string) {
+	w.writeString(name + labelSet + " " + value + "\n")
+}
+
+// labels will format the label pairs (IE: {endpoint="GetUserIdentity"})
+func labels(pairs ...string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i] + `="` + escapeLabelValue(pairs[i+1]) + `"`)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// labelValueEscaper escapes the backslash, double-quote and line feed in label values
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue will escape a label value for the text exposition format
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+// sortedKeys will return the keys of a counter map in order
+func sortedKeys(m map[[2]string]uint64) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
+
+// statusClass will return the class of the status code (IE: 4xx) or none if there was no response
+func statusClass(statusCode int) string {
+	if statusCode <= 0 {
+		return statusClassNoResponse
+	}
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
+
+// formatUint will format a counter value
+func formatUint(value uint64) string {
+	return strconv.FormatUint(value, 10)
+}
+
+// formatFloat will format a float value (IE: a bucket bound or a sum in seconds)
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// metrics will return the Metrics of the client (NopMetrics if not set)
+func (c *Client) metrics() Metrics {
+	if c.Options.Metrics == nil {
+		return NopMetrics{}
+	}
+	return c.Options.Metrics
+}
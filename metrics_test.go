@@ -0,0 +1,222 @@
+package moneybutton
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPrometheusMetrics tests the text exposition format of PrometheusMetrics
+func TestPrometheusMetrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		metrics := NewPrometheusMetrics(nil)
+		var buf bytes.Buffer
+		n, err := metrics.WriteTo(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, int64(buf.Len()), n)
+		assert.Equal(t, `# HELP moneybutton_requests_total Total requests to the MoneyButton API.
+# TYPE moneybutton_requests_total counter
+# HELP moneybutton_request_errors_total Total failed requests to the MoneyButton API.
+# TYPE moneybutton_request_errors_total counter
+# HELP moneybutton_request_duration_seconds Latency of the requests to the MoneyButton API.
+# TYPE moneybutton_request_duration_seconds histogram
+# HELP moneybutton_token_refreshes_total Total access token refreshes.
+# TYPE moneybutton_token_refreshes_total counter
+moneybutton_token_refreshes_total{result="failure"} 0
+moneybutton_token_refreshes_total{result="success"} 0
+`, buf.String())
+	})
+
+	t.Run("observations", func(t *testing.T) {
+		metrics := NewPrometheusMetrics([]float64{1, 0.1})
+		metrics.ObserveRequest(&ResponseInfo{
+			Duration: 50 * time.Millisecond, Endpoint: "GetUserIdentity", Method: http.MethodGet,
+			Response: &RequestResponse{}, StatusCode: http.StatusOK,
+		})
+		metrics.ObserveRequest(&ResponseInfo{
+			Duration: 100 * time.Millisecond, Endpoint: "GetUserIdentity", Method: http.MethodGet,
+			Response: &RequestResponse{Error: errors.New("unauthorized")}, StatusCode: http.StatusUnauthorized,
+		})
+		metrics.ObserveRequest(&ResponseInfo{
+			Duration: 2 * time.Second, Endpoint: "GetBalance", Method: http.MethodGet,
+			Response: &RequestResponse{Error: errors.New("connection refused")},
+		})
+		metrics.ObserveTokenRefresh(nil)
+		metrics.ObserveTokenRefresh(nil)
+		metrics.ObserveTokenRefresh(errors.New("invalid refresh token"))
+
+		var buf bytes.Buffer
+		_, err := metrics.WriteTo(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, `# HELP moneybutton_requests_total Total requests to the MoneyButton API.
+# TYPE moneybutton_requests_total counter
+moneybutton_requests_total{endpoint="GetBalance",method="GET"} 1
+moneybutton_requests_total{endpoint="GetUserIdentity",method="GET"} 2
+# HELP moneybutton_request_errors_total Total failed requests to the MoneyButton API.
+# TYPE moneybutton_request_errors_total counter
+moneybutton_request_errors_total{endpoint="GetBalance",status_class="none"} 1
+moneybutton_request_errors_total{endpoint="GetUserIdentity",status_class="4xx"} 1
+# HELP moneybutton_request_duration_seconds Latency of the requests to the MoneyButton API.
+# TYPE moneybutton_request_duration_seconds histogram
+moneybutton_request_duration_seconds_bucket{endpoint="GetBalance",le="0.1"} 0
+moneybutton_request_duration_seconds_bucket{endpoint="GetBalance",le="1"} 0
+moneybutton_request_duration_seconds_bucket{endpoint="GetBalance",le="+Inf"} 1
+moneybutton_request_duration_seconds_sum{endpoint="GetBalance"} 2
+moneybutton_request_duration_seconds_count{endpoint="GetBalance"} 1
+moneybutton_request_duration_seconds_bucket{endpoint="GetUserIdentity",le="0.1"} 2
+moneybutton_request_duration_seconds_bucket{endpoint="GetUserIdentity",le="1"} 2
+moneybutton_request_duration_seconds_bucket{endpoint="GetUserIdentity",le="+Inf"} 2
+moneybutton_request_duration_seconds_sum{endpoint="GetUserIdentity"} 0.15000000000000002
+moneybutton_request_duration_seconds_count{endpoint="GetUserIdentity"} 2
+# HELP moneybutton_token_refreshes_total Total access token refreshes.
+# TYPE moneybutton_token_refreshes_total counter
+moneybutton_token_refreshes_total{result="failure"} 1
+moneybutton_token_refreshes_total{result="success"} 2
+`, buf.String())
+	})
+
+	t.Run("serve http", func(t *testing.T) {
+		metrics := NewPrometheusMetrics(nil)
+		metrics.ObserveTokenRefresh(nil)
+
+		w := httptest.NewRecorder()
+		metrics.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, prometheusContentType, w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), `moneybutton_token_refreshes_total{result="success"} 1`)
+	})
+
+	t.Run("concurrent observations", func(t *testing.T) {
+		metrics := NewPrometheusMetrics(nil)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				metrics.ObserveRequest(&ResponseInfo{Endpoint: "GetProfile", Method: http.MethodGet})
+				metrics.ObserveTokenRefresh(nil)
+				_, _ = metrics.WriteTo(&bytes.Buffer{})
+			}()
+		}
+		wg.Wait()
+
+		var buf bytes.Buffer
+		_, err := metrics.WriteTo(&buf)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), `moneybutton_requests_total{endpoint="GetProfile",method="GET"} 10`)
+		assert.Contains(t, buf.String(), `moneybutton_request_duration_seconds_count{endpoint="GetProfile"} 10`)
+	})
+}
+
+// TestLabels tests the method labels()
+func TestLabels(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `{}`, labels())
+	assert.Equal(t, `{endpoint="GetProfile",method="GET"}`, labels("endpoint", "GetProfile", "method", "GET"))
+	assert.Equal(t, `{endpoint="a\\b\"c\nd"}`, labels("endpoint", "a\\b\"c\nd"))
+}
+
+// TestStatusClass tests the method statusClass()
+func TestStatusClass(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "none", statusClass(0))
+	assert.Equal(t, "2xx", statusClass(http.StatusCreated))
+	assert.Equal(t, "4xx", statusClass(http.StatusNotFound))
+	assert.Equal(t, "5xx", statusClass(http.StatusBadGateway))
+}
+
+// TestClient_Metrics tests that the Client records the metrics
+func TestClient_Metrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("requests and token refreshes", func(t *testing.T) {
+		metrics := NewPrometheusMetrics(nil)
+		options := DefaultClientOptions()
+		options.Metrics = metrics
+		client := NewClient(options, nil, nil)
+
+		client.SetHTTPClient(&mockHTTPGetUserIdentity{})
+		_, err := client.GetUserIdentity(context.Background(), testSecretAccessToken)
+		require.NoError(t, err)
+
+		client.SetHTTPClient(&mockHTTPRefreshAccessToken{})
+		_, err = client.RefreshAccessToken(context.Background(), "client-id", testSecretRefreshToken)
+		require.NoError(t, err)
+
+		client.SetHTTPClient(&mockHTTPEchoSecrets{})
+		_, err = client.RefreshAccessToken(context.Background(), "client-id", testSecretRefreshToken)
+		require.Error(t, err)
+
+		// Invalid parameters are not requests
+		_, err = client.RefreshAccessToken(context.Background(), "", testSecretRefreshToken)
+		require.Error(t, err)
+
+		var buf bytes.Buffer
+		_, err = metrics.WriteTo(&buf)
+		require.NoError(t, err)
+		text := buf.String()
+		assert.Contains(t, text, `moneybutton_requests_total{endpoint="GetUserIdentity",method="GET"} 1`)
+		assert.Contains(t, text, `moneybutton_requests_total{endpoint="RefreshAccessToken",method="POST"} 2`)
+		assert.Contains(t, text, `moneybutton_request_errors_total{endpoint="RefreshAccessToken",status_class="4xx"} 1`)
+		assert.Contains(t, text, `moneybutton_request_duration_seconds_count{endpoint="RefreshAccessToken"} 2`)
+		assert.Contains(t, text, `moneybutton_token_refreshes_total{result="failure"} 1`)
+		assert.Contains(t, text, `moneybutton_token_refreshes_total{result="success"} 1`)
+		assert.NotContains(t, text, "GetBalance")
+	})
+
+	t.Run("no-op default", func(t *testing.T) {
+		client := NewClient(nil, nil, nil)
+		assert.Equal(t, NopMetrics{}, client.metrics())
+
+		client.SetHTTPClient(&mockHTTPGetUserIdentity{})
+		_, err := client.GetUserIdentity(context.Background(), testSecretAccessToken)
+		require.NoError(t, err)
+	})
+}
+
+// ExamplePrometheusMetrics example using NewPrometheusMetrics()
+func ExamplePrometheusMetrics() {
+	metrics := NewPrometheusMetrics(nil)
+	options := DefaultClientOptions()
+	options.Metrics = metrics
+	client := NewClient(options, nil, nil)
+
+	// Serve the metrics for Prometheus (IE: http.Handle("/metrics", metrics))
+	client.SetHTTPClient(&mockHTTPGetUserIdentity{})
+	if _, err := client.GetUserIdentity(context.Background(), "access-token"); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	w := httptest.NewRecorder()
+	metrics.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if strings.HasPrefix(line, "moneybutton_requests_total") {
+			fmt.Println(line)
+		}
+	}
+	// Output:moneybutton_requests_total{endpoint="GetUserIdentity",method="GET"} 1
+}
+
+// BenchmarkPrometheusMetrics_ObserveRequest benchmarks the method ObserveRequest()
+func BenchmarkPrometheusMetrics_ObserveRequest(b *testing.B) {
+	metrics := NewPrometheusMetrics(nil)
+	info := &ResponseInfo{Duration: 50 * time.Millisecond, Endpoint: "GetUserIdentity", Method: http.MethodGet}
+	for i := 0; i < b.N; i++ {
+		metrics.ObserveRequest(info)
+	}
+}
@@ -0,0 +1,49 @@
+package moneybutton
+
+import (
+	"net/http"
+	"time"
+)
+
+// HTTPInterfaceFunc is an adapter to use a function as an HTTPInterface (IE: inside a Middleware)
+type HTTPInterfaceFunc func(req *http.Request) (*http.Response, error)
+
+// Do will call the function
+func (f HTTPInterfaceFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the HTTP client for every request (IE: add headers, log, measure or alter requests)
+//
+// Middleware is applied in the order given: the first one sees the request first and the response last
+type Middleware func(next HTTPInterface) HTTPInterface
+
+// BeforeRequestHook is called before every request is sent (the request can be changed, IE: adding headers)
+//
+// Returning an error cancels the request, the error is returned by the endpoint method
+type BeforeRequestHook func(req *http.Request) error
+
+// AfterResponseHook is called after every request, including failed requests
+type AfterResponseHook func(info *ResponseInfo)
+
+// ResponseInfo is the result of a request (passed to every AfterResponseHook)
+type ResponseInfo struct {
+	Attempts   int              // Attempts made including retries (0 if the request was not sent)
+	Duration   time.Duration    // Time from the before request hooks until the body was read
+	Endpoint   string           // Name of the Client method (IE: GetUserIdentity)
+	Method     string           // IE: GET
+	Request    *http.Request    // The request that was sent (nil if it could not be created)
+	Response   *RequestResponse // Body, status and error of the request
+	StatusCode int              // Status code (0 if no response was received)
+	URL        string           // Full URL of the request
+}
+
+// chainMiddleware will wrap the HTTP client with the middleware (the first middleware is the outermost)
+func chainMiddleware(httpClient HTTPInterface, middleware []Middleware) HTTPInterface {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if middleware[i] != nil {
+			httpClient = middleware[i](httpClient)
+		}
+	}
+	return httpClient
+}
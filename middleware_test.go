@@ -0,0 +1,213 @@
+package moneybutton
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHTTPMiddleware for mocking requests (records the events and the request headers)
+type mockHTTPMiddleware struct {
+	events  *[]string
+	headers http.Header
+}
+
+// Do is a mock http request
+func (m *mockHTTPMiddleware) Do(req *http.Request) (*http.Response, error) {
+	*m.events = append(*m.events, "client")
+	m.headers = req.Header.Clone()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`{"data":{"type":"user_identities","id":"123"}}`)),
+	}, nil
+}
+
+// recordingMiddleware will return a middleware that records the request and response events
+func recordingMiddleware(events *[]string, name string) Middleware {
+	return func(next HTTPInterface) HTTPInterface {
+		return HTTPInterfaceFunc(func(req *http.Request) (*http.Response, error) {
+			*events = append(*events, name+" request")
+			req.Header.Set("X-"+name, "1")
+			resp, err := next.Do(req)
+			*events = append(*events, name+" response")
+			return resp, err
+		})
+	}
+}
+
+// TestClient_Middleware tests the hooks and middleware of ClientOptions
+func TestClient_Middleware(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ordering", func(t *testing.T) {
+		var events []string
+		options := DefaultClientOptions()
+		options.BeforeRequest = []BeforeRequestHook{
+			func(*http.Request) error { events = append(events, "before 1"); return nil },
+			func(*http.Request) error { events = append(events, "before 2"); return nil },
+		}
+		options.Middleware = []Middleware{recordingMiddleware(&events, "A"), nil, recordingMiddleware(&events, "B")}
+		options.AfterResponse = []AfterResponseHook{
+			func(*ResponseInfo) { events = append(events, "after 1") },
+			func(*ResponseInfo) { events = append(events, "after 2") },
+		}
+		client := NewClient(options, nil, nil)
+		mock := &mockHTTPMiddleware{events: &events}
+		client.SetHTTPClient(mock)
+
+		_, err := client.GetUserIdentity(context.Background(), "token")
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"before 1", "before 2",
+			"A request", "B request", "client", "B response", "A response",
+			"after 1", "after 2",
+		}, events)
+		assert.Equal(t, "1", mock.headers.Get("X-A"))
+		assert.Equal(t, "1", mock.headers.Get("X-B"))
+	})
+
+	t.Run("before request hook changes the request", func(t *testing.T) {
+		var events []string
+		options := DefaultClientOptions()
+		options.BeforeRequest = []BeforeRequestHook{func(req *http.Request) error {
+			req.Header.Set("X-Request-Id", "abc")
+			return nil
+		}}
+		client := NewClient(options, nil, nil)
+		mock := &mockHTTPMiddleware{events: &events}
+		client.SetHTTPClient(mock)
+
+		_, err := client.GetUserIdentity(context.Background(), "token")
+		require.NoError(t, err)
+		assert.Equal(t, "abc", mock.headers.Get("X-Request-Id"))
+		assert.Equal(t, "Bearer token", mock.headers.Get("Authorization"))
+	})
+
+	t.Run("before request hook cancels the request", func(t *testing.T) {
+		var events []string
+		var info *ResponseInfo
+		errCanceled := errors.New("canceled by hook")
+		options := DefaultClientOptions()
+		options.BeforeRequest = []BeforeRequestHook{
+			func(*http.Request) error { return errCanceled },
+			func(*http.Request) error { events = append(events, "before 2"); return nil },
+		}
+		options.AfterResponse = []AfterResponseHook{func(i *ResponseInfo) { info = i }}
+		client := NewClient(options, nil, nil)
+		client.SetHTTPClient(&mockHTTPMiddleware{events: &events})
+
+		_, err := client.GetUserIdentity(context.Background(), "token")
+		assert.ErrorIs(t, err, errCanceled)
+		assert.Empty(t, events)
+		require.NotNil(t, info)
+		assert.ErrorIs(t, info.Response.Error, errCanceled)
+		assert.Equal(t, 0, info.StatusCode)
+	})
+
+	t.Run("after response hook info", func(t *testing.T) {
+		var infos []*ResponseInfo
+		options := DefaultClientOptions()
+		options.AfterResponse = []AfterResponseHook{func(info *ResponseInfo) { infos = append(infos, info) }}
+		client := NewClient(options, nil, nil)
+
+		client.SetHTTPClient(&mockHTTPGetUserIdentity{})
+		_, err := client.GetUserIdentity(context.Background(), "token")
+		require.NoError(t, err)
+
+		client.SetHTTPClient(&mockHTTPAPIError{})
+		_, err = client.GetRefreshToken(context.Background(), "client", "code", "https://example.com")
+		require.Error(t, err)
+
+		require.Len(t, infos, 2)
+		assert.Equal(t, http.MethodGet, infos[0].Method)
+		assert.Equal(t, APIURL+endpointUserIdentity, infos[0].URL)
+		assert.Equal(t, http.StatusOK, infos[0].StatusCode)
+		assert.NotNil(t, infos[0].Request)
+		assert.NotEmpty(t, infos[0].Response.BodyContents)
+		assert.NoError(t, infos[0].Response.Error)
+		assert.Greater(t, int64(infos[0].Duration), int64(0))
+
+		assert.Equal(t, http.MethodPost, infos[1].Method)
+		assert.Equal(t, OauthURL+endpointToken, infos[1].URL)
+		assert.Equal(t, http.StatusBadRequest, infos[1].StatusCode)
+		assert.ErrorIs(t, infos[1].Response.Error, ErrAuthCodeExpired)
+	})
+
+	t.Run("middleware can replace the response", func(t *testing.T) {
+		options := DefaultClientOptions()
+		options.Middleware = []Middleware{func(HTTPInterface) HTTPInterface {
+			return HTTPInterfaceFunc(func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(bytes.NewBufferString(`{"data":{"type":"user_identities","id":"456"}}`)),
+				}, nil
+			})
+		}}
+		client := NewClient(options, nil, nil)
+		client.SetHTTPClient(&mockHTTPAPIError{})
+
+		identity, err := client.GetUserIdentity(context.Background(), "token")
+		require.NoError(t, err)
+		assert.Equal(t, "456", identity.UserID())
+	})
+
+	t.Run("custom http client", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"data":{"type":"user_identities","id":%q}}`, req.Header.Get("X-User"))
+		}))
+		defer server.Close()
+
+		var mu sync.Mutex
+		var statuses []int
+		options := DefaultClientOptions()
+		options.BeforeRequest = []BeforeRequestHook{func(req *http.Request) error {
+			req.Header.Set("X-User", "789")
+			return nil
+		}}
+		options.AfterResponse = []AfterResponseHook{func(info *ResponseInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			statuses = append(statuses, info.StatusCode)
+		}}
+		client := NewClient(options, server.Client(), &Environment{APIURL: server.URL + "/api/v1/"})
+
+		identity, err := client.GetUserIdentity(context.Background(), "token")
+		require.NoError(t, err)
+		assert.Equal(t, "789", identity.UserID())
+		assert.Equal(t, []int{http.StatusOK}, statuses)
+	})
+}
+
+// ExampleMiddleware example using ClientOptions.Middleware and ClientOptions.AfterResponse
+func ExampleMiddleware() {
+	options := DefaultClientOptions()
+
+	// Answer every request locally (a middleware can also add headers, log or retry)
+	options.Middleware = []Middleware{func(next HTTPInterface) HTTPInterface {
+		return HTTPInterfaceFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString(`{"data":{"type":"user_identities","id":"123"}}`)),
+			}, nil
+		})
+	}}
+	options.AfterResponse = []AfterResponseHook{func(info *ResponseInfo) {
+		fmt.Printf("%s %s: %d\n", info.Method, info.URL, info.StatusCode)
+	}}
+
+	client := NewClient(options, nil, nil)
+	if _, err := client.GetUserIdentity(context.Background(), "access-token"); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	// Output:GET https://www.moneybutton.com/api/v1/auth/user_identity: 200
+}
@@ -0,0 +1,355 @@
+package moneybuttontest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tonicpow/go-moneybutton"
+)
+
+// Paths of the fake server
+const (
+	apiPath   = "/api/v1/"
+	oauthPath = "/oauth/v1/"
+)
+
+// Pagination of the payments endpoint
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// identityJSON are the attributes of a user identity
+type identityJSON struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// profileJSON are the attributes of a user profile
+type profileJSON struct {
+	AvatarURL       string `json:"avatar-url"`
+	Bio             string `json:"bio"`
+	CreatedAt       string `json:"created-at"`
+	DefaultCurrency string `json:"default-currency"`
+	DefaultLanguage string `json:"default-language"`
+	Name            string `json:"name"`
+	PrimaryPaymail  string `json:"primary-paymail"`
+}
+
+// balanceJSON are the attributes of a user balance
+type balanceJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+	Satoshis int64  `json:"satoshis"`
+}
+
+// paymentJSON are the attributes of a payment
+type paymentJSON struct {
+	Amount     string `json:"amount"`
+	AmountUSD  string `json:"amount-usd"`
+	ButtonData string `json:"button-data"`
+	ButtonID   string `json:"button-id"`
+	CreatedAt  string `json:"created-at"`
+	Currency   string `json:"currency"`
+	Satoshis   string `json:"satoshis"`
+	Status     string `json:"status"`
+	TxID       string `json:"txid"`
+	UpdatedAt  string `json:"updated-at"`
+	UserID     string `json:"user-id"`
+}
+
+// resourceJSON is a JSON:API resource object
+type resourceJSON struct {
+	Attributes interface{} `json:"attributes"`
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+}
+
+// errorJSON is a JSON:API error object
+type errorJSON struct {
+	Detail string `json:"detail"`
+	ID     string `json:"id"`
+	Status int    `json:"status"`
+	Title  string `json:"title"`
+}
+
+// jsonAPIVersion is the JSON:API version of every document
+var jsonAPIVersion = map[string]string{"version": "1.0"}
+
+// handleAPI will route the REST endpoints
+func (s *Server) handleAPI(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, apiPath), "/")
+
+	var endpoint Endpoint
+	var scope, id string
+	switch {
+	case len(parts) == 2 && parts[0] == "auth" && parts[1] == "user_identity":
+		endpoint, scope = EndpointUserIdentity, moneybutton.PermissionsIdentity
+	case len(parts) == 3 && parts[0] == "users" && parts[2] == "profile":
+		endpoint, scope, id = EndpointUserProfile, moneybutton.PermissionsProfile, parts[1]
+	case len(parts) == 3 && parts[0] == "users" && parts[2] == "balance":
+		endpoint, scope, id = EndpointUserBalance, moneybutton.PermissionsBalance, parts[1]
+	case len(parts) == 1 && parts[0] == "payments":
+		endpoint = EndpointPayments
+	case len(parts) == 2 && parts[0] == "payments":
+		endpoint, id = EndpointPayment, parts[1]
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	if req.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	} else if f := s.nextFailure(endpoint); f != nil {
+		f.write(w)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Check the access token
+	token, status, detail := s.authenticate(req, scope)
+	if token == nil {
+		writeError(w, status, detail)
+		return
+	}
+
+	switch endpoint {
+	case EndpointUserIdentity:
+		s.serveIdentity(w, token)
+	case EndpointUserProfile:
+		s.serveProfile(w, id)
+	case EndpointUserBalance:
+		s.serveBalance(w, token, id)
+	case EndpointPayment:
+		s.servePayment(w, token, id)
+	case EndpointPayments:
+		s.servePayments(w, req, token)
+	}
+}
+
+// authenticate will return the token of the request (must hold the lock)
+func (s *Server) authenticate(req *http.Request, scope string) (*Token, int, string) {
+	accessToken := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	token, ok := s.tokens[accessToken]
+	switch {
+	case len(accessToken) == 0 || !ok:
+		return nil, http.StatusUnauthorized, "Unauthorized: invalid access token"
+	case !s.now().Before(token.ExpiresAt):
+		return nil, http.StatusUnauthorized, "Unauthorized: access token has expired"
+	case len(scope) > 0 && !token.hasScope(scope):
+		return nil, http.StatusForbidden, "Forbidden: insufficient scope (requires " + scope + ")"
+	}
+	return token, 0, ""
+}
+
+// serveIdentity will write the identity of the token user (must hold the lock)
+func (s *Server) serveIdentity(w http.ResponseWriter, token *Token) {
+	user, ok := s.users[token.UserID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	writeResource(w, "user_identities", user.ID, &identityJSON{ID: user.ID, Name: user.Name})
+}
+
+// serveProfile will write the profile of the user (must hold the lock)
+func (s *Server) serveProfile(w http.ResponseWriter, userID string) {
+	user, ok := s.users[userID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	writeResource(w, "profiles", user.ID, &profileJSON{
+		AvatarURL:       user.AvatarURL,
+		Bio:             user.Bio,
+		CreatedAt:       formatTime(user.CreatedAt),
+		DefaultCurrency: user.DefaultCurrency,
+		DefaultLanguage: user.DefaultLanguage,
+		Name:            user.Name,
+		PrimaryPaymail:  user.PrimaryPaymail,
+	})
+}
+
+// serveBalance will write the balance of the token user (must hold the lock)
+func (s *Server) serveBalance(w http.ResponseWriter, token *Token, userID string) {
+	if userID != token.UserID {
+		writeError(w, http.StatusForbidden, "Forbidden: cannot read the balance of another user")
+		return
+	}
+	user, ok := s.users[userID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	writeResource(w, "balances", user.ID, &balanceJSON{
+		Amount:   number(user.Balance.Amount),
+		Currency: user.Balance.Currency,
+		Satoshis: user.Balance.Satoshis,
+	})
+}
+
+// servePayment will write the payment (only payments of the token user are found) (must hold the lock)
+func (s *Server) servePayment(w http.ResponseWriter, token *Token, paymentID string) {
+	payment, ok := s.payments[paymentID]
+	if !ok || payment.UserID != token.UserID {
+		writeError(w, http.StatusNotFound, "Payment not found")
+		return
+	}
+	writeResource(w, "payments", payment.ID, payment.attributes())
+}
+
+// servePayments will write a page of the payments of the token user (must hold the lock)
+func (s *Server) servePayments(w http.ResponseWriter, req *http.Request, token *Token) {
+	query := req.URL.Query()
+
+	// Pagination
+	pageNumber, pageSize := 1, defaultPageSize
+	var err error
+	if value := query.Get("page[number]"); len(value) > 0 {
+		if pageNumber, err = strconv.Atoi(value); err != nil || pageNumber < 1 {
+			writeError(w, http.StatusBadRequest, "Invalid page[number]")
+			return
+		}
+	}
+	if value := query.Get("page[size]"); len(value) > 0 {
+		if pageSize, err = strconv.Atoi(value); err != nil || pageSize < 1 || pageSize > maxPageSize {
+			writeError(w, http.StatusBadRequest, "Invalid page[size]")
+			return
+		}
+	}
+
+	// Filters
+	var createdAfter, createdBefore time.Time
+	if value := query.Get("filter[created-after]"); len(value) > 0 {
+		if createdAfter, err = time.Parse(time.RFC3339, value); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid filter[created-after]")
+			return
+		}
+	}
+	if value := query.Get("filter[created-before]"); len(value) > 0 {
+		if createdBefore, err = time.Parse(time.RFC3339, value); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid filter[created-before]")
+			return
+		}
+	}
+	buttonID, ownerID, status := query.Get("filter[button-id]"), query.Get("filter[owner-id]"), query.Get("filter[status]")
+
+	payments := make([]*Payment, 0, len(s.payments))
+	for _, payment := range s.payments {
+		switch {
+		case payment.UserID != token.UserID,
+			len(buttonID) > 0 && payment.ButtonID != buttonID,
+			len(ownerID) > 0 && payment.UserID != ownerID, // Payments of other owners are never listed
+			len(status) > 0 && string(payment.Status) != status,
+			!createdAfter.IsZero() && payment.CreatedAt.Before(createdAfter),
+			!createdBefore.IsZero() && !payment.CreatedAt.Before(createdBefore):
+			continue
+		}
+		payments = append(payments, payment)
+	}
+
+	// Sort (oldest first unless -created-at)
+	descending := query.Get("sort") == "-created-at"
+	sort.Slice(payments, func(i, j int) bool {
+		if !payments[i].CreatedAt.Equal(payments[j].CreatedAt) {
+			return payments[i].CreatedAt.Before(payments[j].CreatedAt) != descending
+		}
+		return payments[i].ID < payments[j].ID
+	})
+
+	// Build the page
+	data := make([]*resourceJSON, 0, pageSize)
+	for i := (pageNumber - 1) * pageSize; i < len(payments) && i < pageNumber*pageSize; i++ {
+		data = append(data, &resourceJSON{Attributes: payments[i].attributes(), ID: payments[i].ID, Type: "payments"})
+	}
+	links := map[string]string{"self": s.pageURL(req, query, pageNumber)}
+	if pageNumber*pageSize < len(payments) {
+		links["next"] = s.pageURL(req, query, pageNumber+1)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    data,
+		"jsonapi": jsonAPIVersion,
+		"links":   links,
+		"meta":    map[string]int{"total-count": len(payments)},
+	})
+}
+
+// pageURL will return the URL of the page
+func (s *Server) pageURL(req *http.Request, query url.Values, pageNumber int) string {
+	pageQuery := url.Values{}
+	for key, values := range query {
+		pageQuery[key] = values
+	}
+	pageQuery.Set("page[number]", strconv.Itoa(pageNumber))
+	return s.server.URL + req.URL.Path + "?" + pageQuery.Encode()
+}
+
+// attributes will return the JSON:API attributes of the payment
+func (p *Payment) attributes() *paymentJSON {
+	return &paymentJSON{
+		Amount:     number(p.Amount),
+		AmountUSD:  number(p.AmountUSD),
+		ButtonData: p.ButtonData,
+		ButtonID:   p.ButtonID,
+		CreatedAt:  formatTime(p.CreatedAt),
+		Currency:   p.Currency,
+		Satoshis:   number(p.Satoshis),
+		Status:     string(p.Status),
+		TxID:       p.TxID,
+		UpdatedAt:  formatTime(p.UpdatedAt),
+		UserID:     p.UserID,
+	}
+}
+
+// number will return the amount (0 if not set, the client decodes amounts as numbers)
+func number(amount string) string {
+	if len(amount) == 0 {
+		return "0"
+	}
+	return amount
+}
+
+// formatTime will format the time like the MoneyButton API (empty if not set)
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// writeResource will write a JSON:API document with a single resource
+func writeResource(w http.ResponseWriter, resourceType, id string, attributes interface{}) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data":    &resourceJSON{Attributes: attributes, ID: id, Type: resourceType},
+		"jsonapi": jsonAPIVersion,
+	})
+}
+
+// writeError will write a JSON:API error document
+func writeError(w http.ResponseWriter, status int, detail string) {
+	writeJSON(w, status, map[string]interface{}{
+		"errors": []*errorJSON{{
+			Detail: detail,
+			ID:     randomID(),
+			Status: status,
+			Title:  http.StatusText(status),
+		}},
+		"jsonapi": jsonAPIVersion,
+	})
+}
+
+// writeJSON will write the value as JSON
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,236 @@
+package moneybuttontest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tonicpow/go-moneybutton"
+)
+
+// allScopes are all the scopes of the fake server
+var allScopes = []string{moneybutton.PermissionsBalance, moneybutton.PermissionsIdentity, moneybutton.PermissionsProfile}
+
+// TestServer_UserEndpoints tests the identity, profile and balance endpoints
+func TestServer_UserEndpoints(t *testing.T) {
+	t.Parallel()
+
+	t.Run("identity", func(t *testing.T) {
+		server := newTestServer(t)
+		token := server.IssueToken(testClientID, "123", allScopes...)
+		identity, err := server.NewClient().GetUserIdentity(context.Background(), token.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, &moneybutton.Identity{ID: "123", Name: "MrZ"}, identity.Identity())
+	})
+
+	t.Run("profile", func(t *testing.T) {
+		server := newTestServer(t)
+		token := server.IssueToken(testClientID, "123", allScopes...)
+		profile, err := server.NewClient().GetProfile(context.Background(), "123", token.AccessToken)
+		require.NoError(t, err)
+
+		attributes := profile.Profile()
+		require.NotNil(t, attributes)
+		assert.Equal(t, "MrZ", attributes.Name)
+		assert.Equal(t, "https://www.gravatar.com/avatar/123", attributes.AvatarURL.String())
+		assert.Equal(t, time.Date(2019, 3, 26, 17, 33, 42, 788000000, time.UTC), attributes.CreatedAt)
+		assert.Equal(t, moneybutton.Paymail("mrz@moneybutton.com"), attributes.PrimaryPaymail)
+
+		_, err = server.NewClient().GetProfile(context.Background(), "999", token.AccessToken)
+		var apiErr *moneybutton.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	})
+
+	t.Run("balance", func(t *testing.T) {
+		server := newTestServer(t)
+		token := server.IssueToken(testClientID, "123", allScopes...)
+		client := server.NewClient()
+
+		balance, err := client.GetBalance(context.Background(), "123", token.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "12.34", balance.Data.Attributes.Amount.String())
+		assert.Equal(t, "5000000", balance.Balance().Satoshis.String())
+
+		server.SetBalance("123", Balance{Currency: "EUR"})
+		balance, err = client.GetBalance(context.Background(), "123", token.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "0", balance.Data.Attributes.Amount.String())
+		assert.Equal(t, "EUR", balance.Data.Attributes.Currency)
+
+		// Another user
+		server.AddUser(&User{ID: "456"})
+		_, err = client.GetBalance(context.Background(), "456", token.AccessToken)
+		assert.ErrorIs(t, err, moneybutton.ErrInsufficientScope)
+	})
+
+	t.Run("missing scope", func(t *testing.T) {
+		server := newTestServer(t)
+		token := server.IssueToken(testClientID, "123", moneybutton.PermissionsIdentity)
+		client := server.NewClient()
+
+		_, err := client.GetProfile(context.Background(), "123", token.AccessToken)
+		assert.ErrorIs(t, err, moneybutton.ErrInsufficientScope)
+		_, err = client.GetBalance(context.Background(), "123", token.AccessToken)
+		assert.ErrorIs(t, err, moneybutton.ErrInsufficientScope)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		server := newTestServer(t)
+		token := server.IssueToken(testClientID, "999", allScopes...)
+		_, err := server.NewClient().GetUserIdentity(context.Background(), token.AccessToken)
+		var apiErr *moneybutton.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	})
+
+	t.Run("invalid access token", func(t *testing.T) {
+		server := newTestServer(t)
+		_, err := server.NewClient().GetUserIdentity(context.Background(), "unknown")
+		assert.ErrorIs(t, err, moneybutton.ErrUnauthorized)
+	})
+
+	t.Run("unknown endpoint and method", func(t *testing.T) {
+		server := newTestServer(t)
+		resp, err := http.Get(server.URL() + "/api/v1/unknown")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp, err = http.Post(server.URL()+"/api/v1/payments", "application/json", nil)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+		resp, err = http.Get(server.URL() + "/oauth/v1/token")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	})
+}
+
+// TestServer_PaymentEndpoints tests the payment endpoints
+func TestServer_PaymentEndpoints(t *testing.T) {
+	t.Parallel()
+
+	// newPaymentServer will start a server with 25 payments for user 123 and one for user 456
+	newPaymentServer := func(t *testing.T) (*Server, string) {
+		server := newTestServer(t)
+		start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := 1; i <= 25; i++ {
+			status := moneybutton.PaymentStatusCompleted
+			if i%5 == 0 {
+				status = moneybutton.PaymentStatusFailed
+			}
+			server.AddPayment(&Payment{
+				Amount:    "0.01",
+				ButtonID:  fmt.Sprintf("button-%d", i%2),
+				CreatedAt: start.Add(time.Duration(i) * time.Hour),
+				Currency:  "USD",
+				ID:        fmt.Sprintf("%d", i),
+				Status:    status,
+				UserID:    "123",
+			})
+		}
+		server.AddPayment(&Payment{ID: "other", Status: moneybutton.PaymentStatusCompleted, UserID: "456"})
+		return server, server.IssueToken(testClientID, "123").AccessToken
+	}
+
+	t.Run("get payment", func(t *testing.T) {
+		server, accessToken := newPaymentServer(t)
+		client := server.NewClient()
+
+		payment, err := client.GetPayment(context.Background(), "1", accessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "1", payment.Data.ID)
+		assert.Equal(t, moneybutton.PaymentStatusCompleted, payment.Data.Attributes.Status)
+		assert.Equal(t, "0.01", payment.Data.Attributes.Amount.String())
+		assert.Equal(t, "0", payment.Data.Attributes.Satoshis.String())
+
+		server.SetPaymentStatus("1", moneybutton.PaymentStatusRefunded)
+		payment, err = client.GetPayment(context.Background(), "1", accessToken)
+		require.NoError(t, err)
+		assert.Equal(t, moneybutton.PaymentStatusRefunded, payment.Data.Attributes.Status)
+
+		// Payments of other users are not found
+		for _, paymentID := range []string{"other", "unknown"} {
+			_, err = client.GetPayment(context.Background(), paymentID, accessToken)
+			var apiErr *moneybutton.APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		}
+	})
+
+	t.Run("list payments", func(t *testing.T) {
+		server, accessToken := newPaymentServer(t)
+		payments, err := server.NewClient().ListPayments(context.Background(), accessToken, &moneybutton.ListPaymentsOptions{PageSize: 10})
+		require.NoError(t, err)
+		require.Len(t, payments.Data, 10)
+		assert.Equal(t, "1", payments.Data[0].ID)
+		assert.NotEmpty(t, payments.Links.Href("self"))
+		assert.NotEmpty(t, payments.Links.Href("next"))
+		assert.Equal(t, float64(25), payments.Meta["total-count"])
+	})
+
+	t.Run("filters and sort", func(t *testing.T) {
+		server, accessToken := newPaymentServer(t)
+		payments, err := server.NewClient().ListPayments(context.Background(), accessToken, &moneybutton.ListPaymentsOptions{
+			ButtonID:     "button-1",
+			CreatedAfter: time.Date(2021, 1, 1, 5, 0, 0, 0, time.UTC),
+			Sort:         moneybutton.SortCreatedAtDesc,
+			Status:       moneybutton.PaymentStatusCompleted,
+		})
+		require.NoError(t, err)
+
+		var ids []string
+		for _, payment := range payments.Data {
+			ids = append(ids, payment.ID)
+		}
+		assert.Equal(t, []string{"23", "21", "19", "17", "13", "11", "9", "7"}, ids)
+		assert.Equal(t, "", payments.Links.Href("next"))
+	})
+
+	t.Run("owner filter", func(t *testing.T) {
+		server, accessToken := newPaymentServer(t)
+		client := server.NewClient()
+
+		payments, err := client.ListPayments(context.Background(), accessToken, &moneybutton.ListPaymentsOptions{OwnerID: "123"})
+		require.NoError(t, err)
+		assert.Equal(t, float64(25), payments.Meta["total-count"])
+
+		// Payments of other users are not listed
+		payments, err = client.ListPayments(context.Background(), accessToken, &moneybutton.ListPaymentsOptions{OwnerID: "456"})
+		require.NoError(t, err)
+		assert.Empty(t, payments.Data)
+		assert.Equal(t, float64(0), payments.Meta["total-count"])
+	})
+
+	t.Run("iterator walks every page", func(t *testing.T) {
+		server, accessToken := newPaymentServer(t)
+		it := server.NewClient().PaymentIterator(accessToken, &moneybutton.ListPaymentsOptions{PageSize: 10})
+		count := 0
+		for it.Next(context.Background()) {
+			count++
+		}
+		require.NoError(t, it.Err())
+		assert.Equal(t, 25, count)
+		assert.Equal(t, 3, server.Requests(EndpointPayments))
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		server, accessToken := newPaymentServer(t)
+		for _, query := range []string{"page[number]=0", "page[size]=101", "filter[created-after]=yesterday", "filter[created-before]=x"} {
+			req, err := http.NewRequest(http.MethodGet, server.URL()+"/api/v1/payments?"+query, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+accessToken)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+		}
+	})
+}
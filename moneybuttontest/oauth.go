@@ -0,0 +1,366 @@
+package moneybuttontest
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tonicpow/go-moneybutton"
+)
+
+// PKCE code challenge methods (RFC 7636)
+const (
+	challengeMethodPlain = "plain"
+	challengeMethodS256  = "S256"
+)
+
+// Errors returned on the authorization redirect (RFC 6749 section 4.1.2.1)
+const (
+	authorizeAccessDenied            = "access_denied"
+	authorizeInvalidRequest          = "invalid_request"
+	authorizeInvalidScope            = "invalid_scope"
+	authorizeLoginRequired           = "login_required"
+	authorizeUnsupportedResponseType = "unsupported_response_type"
+)
+
+// knownScopes are the scopes the fake server can grant
+var knownScopes = map[string]bool{
+	moneybutton.PermissionsBalance:  true,
+	moneybutton.PermissionsIdentity: true,
+	moneybutton.PermissionsProfile:  true,
+}
+
+// App is a registered oAuth client (required for the authorization endpoint)
+type App struct {
+	ClientID     string   // Client ID (required)
+	RedirectURIs []string // Allowed redirect URIs (exact match)
+}
+
+// Consent is how the simulated user answers the consent screen
+type Consent struct {
+	Approve bool     // False simulates the user clicking "deny"
+	Scopes  []string // Scopes the user grants (nil grants every requested scope)
+	UserID  string   // User that is logged in and authorizes the client (required)
+}
+
+// tokenJSON is the response of the token endpoint
+type tokenJSON struct {
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    uint32 `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+	TokenType    string `json:"token_type"`
+}
+
+// AddApp will register (or replace) the oAuth client
+func (s *Server) AddApp(app *App) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	appCopy := *app
+	appCopy.RedirectURIs = append([]string(nil), app.RedirectURIs...)
+	s.apps[app.ClientID] = &appCopy
+}
+
+// SetConsent will change how the user answers the consent screen (nil simulates a logged out user)
+func (s *Server) SetConsent(consent *Consent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if consent == nil {
+		s.consent = nil
+		return
+	}
+	consentCopy := *consent
+	if consent.Scopes != nil {
+		consentCopy.Scopes = append(make([]string, 0, len(consent.Scopes)), consent.Scopes...)
+	}
+	s.consent = &consentCopy
+}
+
+// Authorize will follow the authorization URL (IE: from GetAuthorizationURLWithPKCE) as a browser would
+// and return the redirect URL (with either the code and state, or the error and state)
+//
+// An error is returned if the server did not redirect (unknown client or redirect URI)
+func (s *Server) Authorize(authorizationURL string) (*url.URL, error) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(authorizationURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusFound {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("authorization failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp.Location()
+}
+
+// handleAuthorize will simulate the consent screen and redirect back to the client
+//
+// Errors about the client or redirect URI are shown to the user (no redirect), every other
+// error is returned on the redirect (IE: ?error=access_denied&state=...)
+func (s *Server) handleAuthorize(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	} else if f := s.nextFailure(EndpointAuthorize); f != nil {
+		f.write(w)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := req.URL.Query()
+	clientID, redirectURI, state := query.Get("client_id"), query.Get("redirect_uri"), query.Get("state")
+	app, ok := s.apps[clientID]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid request: unknown client_id")
+		return
+	} else if !app.allowsRedirect(redirectURI) {
+		writeError(w, http.StatusBadRequest, "Invalid request: redirect_uri is not registered for the client")
+		return
+	}
+
+	code, errorCode := s.authorizeCode(query)
+	redirect, _ := url.Parse(redirectURI)
+	values := redirect.Query()
+	if len(errorCode) > 0 {
+		values.Set("error", errorCode)
+	} else {
+		values.Set("code", code)
+	}
+	if len(state) > 0 {
+		values.Set("state", state)
+	}
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, req, redirect.String(), http.StatusFound)
+}
+
+// authorizeCode will validate the authorization request and issue a code (must hold the lock)
+func (s *Server) authorizeCode(query url.Values) (string, string) {
+	if query.Get("response_type") != "code" {
+		return "", authorizeUnsupportedResponseType
+	}
+
+	scopes := strings.Fields(query.Get("scope"))
+	if len(scopes) == 0 {
+		return "", authorizeInvalidScope
+	}
+	for _, scope := range scopes {
+		if !knownScopes[scope] {
+			return "", authorizeInvalidScope
+		}
+	}
+
+	// The challenge method defaults to plain (RFC 7636 section 4.3)
+	challenge, method := query.Get("code_challenge"), query.Get("code_challenge_method")
+	if len(challenge) == 0 && len(method) > 0 {
+		return "", authorizeInvalidRequest
+	} else if len(challenge) > 0 && len(method) == 0 {
+		method = challengeMethodPlain
+	}
+	if len(method) > 0 && method != challengeMethodPlain && method != challengeMethodS256 {
+		return "", authorizeInvalidRequest
+	}
+
+	// Simulate the user on the consent screen
+	if s.consent == nil {
+		return "", authorizeLoginRequired
+	} else if !s.consent.Approve {
+		return "", authorizeAccessDenied
+	}
+	granted := scopes
+	if s.consent.Scopes != nil {
+		granted = intersectScopes(scopes, s.consent.Scopes)
+		if len(granted) == 0 {
+			return "", authorizeAccessDenied
+		}
+	}
+
+	code := &Code{
+		ClientID:            query.Get("client_id"),
+		Code:                randomID(),
+		CodeChallenge:       challenge,
+		CodeChallengeMethod: method,
+		ExpiresAt:           s.now().Add(DefaultCodeLifetime),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scopes:              granted,
+		State:               query.Get("state"),
+		UserID:              s.consent.UserID,
+	}
+	s.codes[code.Code] = code
+	return code.Code, ""
+}
+
+// allowsRedirect will return true if the redirect URI is registered for the app
+func (a *App) allowsRedirect(redirectURI string) bool {
+	for _, allowed := range a.RedirectURIs {
+		if allowed == redirectURI {
+			return true
+		}
+	}
+	return false
+}
+
+// intersectScopes will return the requested scopes that are also granted (in requested order)
+func intersectScopes(requested, granted []string) []string {
+	var scopes []string
+	for _, scope := range requested {
+		for _, g := range granted {
+			if scope == g {
+				scopes = append(scopes, scope)
+				break
+			}
+		}
+	}
+	return scopes
+}
+
+// handleToken will exchange an authorization code or a refresh token for a new token
+func (s *Server) handleToken(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	} else if f := s.nextFailure(EndpointToken); f != nil {
+		f.write(w)
+		return
+	} else if err := req.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var token *Token
+	var status int
+	var detail string
+	switch req.PostForm.Get("grant_type") {
+	case "authorization_code":
+		token, status, detail = s.exchangeCode(req.PostForm)
+	case "refresh_token":
+		token, status, detail = s.refreshToken(req.PostForm)
+	default:
+		status, detail = http.StatusBadRequest, "Unsupported grant type"
+	}
+	if token == nil {
+		writeError(w, status, detail)
+		return
+	}
+
+	response := token.response(s.now())
+	writeJSON(w, http.StatusOK, &tokenJSON{
+		AccessToken:  response.AccessToken,
+		ExpiresIn:    response.ExpiresIn,
+		RefreshToken: response.RefreshToken,
+		Scope:        response.Scope,
+		TokenType:    response.TokenType,
+	})
+}
+
+// exchangeCode will exchange the authorization code for a token (must hold the lock)
+//
+// Reusing a code revokes the token that was issued for it (RFC 6749 section 4.1.2)
+func (s *Server) exchangeCode(form url.Values) (*Token, int, string) {
+	clientID, codeValue, redirectURI := form.Get("client_id"), form.Get("code"), form.Get("redirect_uri")
+	if len(clientID) == 0 || len(codeValue) == 0 || len(redirectURI) == 0 {
+		return nil, http.StatusBadRequest, "Invalid request: missing client_id, code or redirect_uri"
+	}
+
+	code, ok := s.codes[codeValue]
+	if !ok {
+		if token, used := s.usedCodes[codeValue]; used {
+			s.revoke(token)
+			return nil, http.StatusBadRequest, "Invalid grant: authorization code has already been used"
+		}
+		return nil, http.StatusBadRequest, "Invalid grant: authorization code is invalid"
+	}
+
+	// Codes are single use
+	delete(s.codes, codeValue)
+	switch {
+	case !s.now().Before(code.ExpiresAt):
+		return nil, http.StatusBadRequest, "Invalid grant: authorization code has expired"
+	case code.ClientID != clientID:
+		return nil, http.StatusBadRequest, "Invalid grant: authorization code was issued to another client"
+	case code.RedirectURI != redirectURI:
+		return nil, http.StatusBadRequest, "Invalid grant: redirect_uri does not match the authorization code"
+	}
+	if err := code.verify(form.Get("code_verifier")); err != nil {
+		return nil, http.StatusBadRequest, "Invalid grant: " + err.Error()
+	}
+
+	token := s.issueToken(code.ClientID, code.UserID, code.Scopes)
+	s.usedCodes[codeValue] = token
+	return token, 0, ""
+}
+
+// verify will check the PKCE code verifier against the code challenge (if any)
+func (c *Code) verify(codeVerifier string) error {
+	if len(c.CodeChallenge) == 0 {
+		return nil
+	} else if len(codeVerifier) == 0 {
+		return errors.New("code_verifier is required for the authorization code")
+	}
+
+	challenge := codeVerifier
+	if c.CodeChallengeMethod == challengeMethodS256 {
+		sum := sha256.Sum256([]byte(codeVerifier))
+		challenge = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	if subtle.ConstantTimeCompare([]byte(challenge), []byte(c.CodeChallenge)) != 1 {
+		return errors.New("code_verifier does not match the authorization code")
+	}
+	return nil
+}
+
+// refreshToken will issue a new access token for the refresh token (must hold the lock)
+//
+// With rotation, a new refresh token is issued every time and reusing an old one
+// revokes the token (as a stolen refresh token is assumed)
+func (s *Server) refreshToken(form url.Values) (*Token, int, string) {
+	clientID, refreshToken := form.Get("client_id"), form.Get("refresh_token")
+	if len(clientID) == 0 || len(refreshToken) == 0 {
+		return nil, http.StatusBadRequest, "Invalid request: missing client_id or refresh_token"
+	}
+
+	token, ok := s.refreshTokens[refreshToken]
+	if !ok {
+		if rotated, used := s.rotatedTokens[refreshToken]; used {
+			s.revoke(rotated)
+			return nil, http.StatusBadRequest, "Invalid grant: refresh token has already been used"
+		}
+		return nil, http.StatusBadRequest, "Invalid grant: refresh token is invalid"
+	} else if token.ClientID != clientID {
+		return nil, http.StatusBadRequest, "Invalid grant: refresh token was issued to another client"
+	} else if !token.RefreshExpiresAt.IsZero() && !s.now().Before(token.RefreshExpiresAt) {
+		return nil, http.StatusBadRequest, "Invalid grant: refresh token has expired"
+	}
+
+	// New access token (and a new refresh token with rotation)
+	delete(s.tokens, token.AccessToken)
+	token.AccessToken = randomID()
+	token.ExpiresAt = s.now().Add(s.tokenLifetime)
+	s.tokens[token.AccessToken] = token
+	if s.rotateRefreshTokens {
+		delete(s.refreshTokens, token.RefreshToken)
+		s.rotatedTokens[token.RefreshToken] = token
+		token.RefreshToken = randomID()
+		token.RefreshExpiresAt = s.refreshExpiry()
+		s.refreshTokens[token.RefreshToken] = token
+	}
+	return token, 0, ""
+}
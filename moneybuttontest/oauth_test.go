@@ -0,0 +1,396 @@
+package moneybuttontest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tonicpow/go-moneybutton"
+)
+
+// testRedirectURI is the redirect URI of the test app
+const testRedirectURI = "https://example.com/callback"
+
+// newOAuthServer will start a server with a registered app and an approving user
+func newOAuthServer(t *testing.T) *Server {
+	server := newTestServer(t)
+	server.AddApp(&App{ClientID: testClientID, RedirectURIs: []string{testRedirectURI}})
+	server.SetConsent(&Consent{Approve: true, UserID: "123"})
+	return server
+}
+
+// authorize will follow the authorization URL and return the redirect query
+func authorize(t *testing.T, server *Server, pkce *moneybutton.PKCE, scopes ...string) url.Values {
+	client := server.NewClient()
+	var authURL string
+	var err error
+	if pkce != nil {
+		authURL, err = client.GetAuthorizationURLWithPKCE(testClientID, testRedirectURI, scopes, "xyz", pkce)
+	} else {
+		authURL, err = client.GetAuthorizationURL(testClientID, testRedirectURI, scopes, "xyz")
+	}
+	require.NoError(t, err)
+
+	redirect, err := server.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", redirect.Host)
+	assert.Equal(t, "/callback", redirect.Path)
+	return redirect.Query()
+}
+
+// TestServer_Authorize tests the authorization endpoint
+func TestServer_Authorize(t *testing.T) {
+	t.Parallel()
+
+	t.Run("approved with pkce", func(t *testing.T) {
+		server := newOAuthServer(t)
+		pkce, err := moneybutton.NewPKCE()
+		require.NoError(t, err)
+
+		query := authorize(t, server, pkce, moneybutton.PermissionsIdentity, moneybutton.PermissionsProfile)
+		assert.Equal(t, "xyz", query.Get("state"))
+		assert.Empty(t, query.Get("error"))
+		require.NotEmpty(t, query.Get("code"))
+		assert.Equal(t, 1, server.Requests(EndpointAuthorize))
+
+		client := server.NewClient()
+		token, err := client.GetRefreshTokenWithPKCE(
+			context.Background(), testClientID, query.Get("code"), testRedirectURI, pkce.CodeVerifier,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, moneybutton.PermissionsIdentity+" "+moneybutton.PermissionsProfile, token.Scope)
+
+		identity, err := client.GetUserIdentity(context.Background(), token.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "123", identity.UserID())
+	})
+
+	t.Run("approved without pkce", func(t *testing.T) {
+		server := newOAuthServer(t)
+		query := authorize(t, server, nil, moneybutton.PermissionsIdentity)
+		_, err := server.NewClient().GetRefreshToken(context.Background(), testClientID, query.Get("code"), testRedirectURI)
+		require.NoError(t, err)
+	})
+
+	t.Run("user grants fewer scopes", func(t *testing.T) {
+		server := newOAuthServer(t)
+		server.SetConsent(&Consent{Approve: true, Scopes: []string{moneybutton.PermissionsIdentity}, UserID: "123"})
+
+		query := authorize(t, server, nil, moneybutton.PermissionsProfile, moneybutton.PermissionsIdentity)
+		token, err := server.NewClient().GetRefreshToken(context.Background(), testClientID, query.Get("code"), testRedirectURI)
+		require.NoError(t, err)
+		assert.Equal(t, moneybutton.PermissionsIdentity, token.Scope)
+
+		_, err = server.NewClient().GetProfile(context.Background(), "123", token.AccessToken)
+		assert.ErrorIs(t, err, moneybutton.ErrInsufficientScope)
+	})
+
+	t.Run("errors on the redirect", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			consent  *Consent
+			scopes   []string
+			expected string
+		}{
+			{"logged out", nil, []string{moneybutton.PermissionsIdentity}, "login_required"},
+			{"denied", &Consent{UserID: "123"}, []string{moneybutton.PermissionsIdentity}, "access_denied"},
+			{
+				"no granted scopes",
+				&Consent{Approve: true, Scopes: []string{moneybutton.PermissionsBalance}, UserID: "123"},
+				[]string{moneybutton.PermissionsIdentity},
+				"access_denied",
+			},
+			{"unknown scope", &Consent{Approve: true, UserID: "123"}, []string{"unknown"}, "invalid_scope"},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				server := newOAuthServer(t)
+				server.SetConsent(test.consent)
+				query := authorize(t, server, nil, test.scopes...)
+				assert.Equal(t, test.expected, query.Get("error"))
+				assert.Equal(t, "xyz", query.Get("state"))
+				assert.Empty(t, query.Get("code"))
+			})
+		}
+	})
+
+	t.Run("invalid requests on the redirect", func(t *testing.T) {
+		server := newOAuthServer(t)
+		base := server.URL() + "/oauth/v1/authorize?client_id=" + testClientID +
+			"&redirect_uri=" + url.QueryEscape(testRedirectURI) + "&state=abc&scope=" + url.QueryEscape(moneybutton.PermissionsIdentity)
+
+		tests := []struct {
+			query    string
+			expected string
+		}{
+			{"&response_type=token", "unsupported_response_type"},
+			{"&response_type=code&code_challenge=abc&code_challenge_method=S512", "invalid_request"},
+			{"&response_type=code&code_challenge_method=S256", "invalid_request"},
+			{"&response_type=code&code_challenge=abc&code_challenge_method=plain", ""},
+			{"&response_type=code&code_challenge=abc", ""},
+		}
+		for _, test := range tests {
+			redirect, err := server.Authorize(base + test.query)
+			require.NoError(t, err, test.query)
+			assert.Equal(t, test.expected, redirect.Query().Get("error"), test.query)
+			assert.Equal(t, "abc", redirect.Query().Get("state"), test.query)
+		}
+	})
+
+	t.Run("redirect uri keeps its query", func(t *testing.T) {
+		server := newOAuthServer(t)
+		server.AddApp(&App{ClientID: "other", RedirectURIs: []string{"https://example.com/cb?app=1"}})
+		authURL, err := server.NewClient().GetAuthorizationURL(
+			"other", "https://example.com/cb?app=1", moneybutton.Scopes{moneybutton.PermissionsIdentity}, "",
+		)
+		require.NoError(t, err)
+
+		redirect, err := server.Authorize(authURL)
+		require.NoError(t, err)
+		assert.Equal(t, "1", redirect.Query().Get("app"))
+		assert.NotEmpty(t, redirect.Query().Get("code"))
+		assert.Empty(t, redirect.Query().Get("state"))
+	})
+
+	t.Run("unknown client or redirect is not redirected", func(t *testing.T) {
+		server := newOAuthServer(t)
+		client := server.NewClient()
+		scopes := moneybutton.Scopes{moneybutton.PermissionsIdentity}
+
+		authURL, err := client.GetAuthorizationURL("unknown", testRedirectURI, scopes, "xyz")
+		require.NoError(t, err)
+		_, err = server.Authorize(authURL)
+		assert.ErrorContains(t, err, "unknown client_id")
+
+		authURL, err = client.GetAuthorizationURL(testClientID, "https://evil.com/callback", scopes, "xyz")
+		require.NoError(t, err)
+		_, err = server.Authorize(authURL)
+		assert.ErrorContains(t, err, "redirect_uri is not registered")
+	})
+
+	t.Run("method not allowed and injected errors", func(t *testing.T) {
+		server := newOAuthServer(t)
+		resp, err := http.Post(server.URL()+"/oauth/v1/authorize", "application/json", nil)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+		server.FailNext(EndpointAuthorize, http.StatusServiceUnavailable, "Maintenance")
+		_, err = server.Authorize(server.URL() + "/oauth/v1/authorize")
+		assert.ErrorContains(t, err, "503")
+	})
+}
+
+// TestServer_ExchangeCode tests PKCE verification and code reuse on the token endpoint
+func TestServer_ExchangeCode(t *testing.T) {
+	t.Parallel()
+
+	t.Run("pkce verifier is checked", func(t *testing.T) {
+		server := newOAuthServer(t)
+		pkce, err := moneybutton.NewPKCE()
+		require.NoError(t, err)
+		other, err := moneybutton.NewPKCE()
+		require.NoError(t, err)
+		client := server.NewClient()
+
+		// Wrong verifier
+		query := authorize(t, server, pkce, moneybutton.PermissionsIdentity)
+		_, err = client.GetRefreshTokenWithPKCE(
+			context.Background(), testClientID, query.Get("code"), testRedirectURI, other.CodeVerifier,
+		)
+		assert.ErrorIs(t, err, moneybutton.ErrAuthCodeExpired)
+
+		// Missing verifier
+		query = authorize(t, server, pkce, moneybutton.PermissionsIdentity)
+		_, err = client.GetRefreshToken(context.Background(), testClientID, query.Get("code"), testRedirectURI)
+		assert.ErrorIs(t, err, moneybutton.ErrAuthCodeExpired)
+		var apiErr *moneybutton.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "Invalid grant: code_verifier is required for the authorization code", apiErr.Errors[0].Detail)
+	})
+
+	t.Run("plain challenge", func(t *testing.T) {
+		server := newOAuthServer(t)
+		verifier := "0123456789012345678901234567890123456789012"
+		code := server.AddCode(&Code{
+			ClientID: testClientID, CodeChallenge: verifier, CodeChallengeMethod: "plain",
+			RedirectURI: testRedirectURI, UserID: "123",
+		})
+		_, err := server.NewClient().GetRefreshTokenWithPKCE(
+			context.Background(), testClientID, code, testRedirectURI, verifier,
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("expired code", func(t *testing.T) {
+		server := newOAuthServer(t)
+		now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+		server.SetNow(func() time.Time { return now })
+
+		query := authorize(t, server, nil, moneybutton.PermissionsIdentity)
+		now = now.Add(DefaultCodeLifetime)
+		_, err := server.NewClient().GetRefreshToken(context.Background(), testClientID, query.Get("code"), testRedirectURI)
+		assert.ErrorIs(t, err, moneybutton.ErrAuthCodeExpired)
+	})
+
+	t.Run("reused code revokes the token", func(t *testing.T) {
+		server := newOAuthServer(t)
+		client := server.NewClient()
+		query := authorize(t, server, nil, moneybutton.PermissionsIdentity)
+
+		token, err := client.GetRefreshToken(context.Background(), testClientID, query.Get("code"), testRedirectURI)
+		require.NoError(t, err)
+
+		_, err = client.GetRefreshToken(context.Background(), testClientID, query.Get("code"), testRedirectURI)
+		assert.ErrorIs(t, err, moneybutton.ErrAuthCodeExpired)
+		var apiErr *moneybutton.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "Invalid grant: authorization code has already been used", apiErr.Errors[0].Detail)
+
+		_, err = client.GetUserIdentity(context.Background(), token.AccessToken)
+		assert.ErrorIs(t, err, moneybutton.ErrUnauthorized)
+		_, err = client.RefreshAccessToken(context.Background(), testClientID, token.RefreshToken)
+		assert.ErrorIs(t, err, moneybutton.ErrInvalidRefreshToken)
+	})
+}
+
+// TestServer_RefreshTokenRotation tests the methods SetRefreshTokenRotation() and SetRefreshTokenLifetime()
+func TestServer_RefreshTokenRotation(t *testing.T) {
+	t.Parallel()
+
+	t.Run("refresh token is rotated", func(t *testing.T) {
+		server := newTestServer(t)
+		token := server.IssueToken(testClientID, "123", moneybutton.PermissionsIdentity)
+		client := server.NewClient()
+
+		refreshed, err := client.RefreshAccessToken(context.Background(), testClientID, token.RefreshToken)
+		require.NoError(t, err)
+		assert.NotEqual(t, token.RefreshToken, refreshed.RefreshToken)
+
+		again, err := client.RefreshAccessToken(context.Background(), testClientID, refreshed.RefreshToken)
+		require.NoError(t, err)
+		assert.NotEqual(t, refreshed.RefreshToken, again.RefreshToken)
+	})
+
+	t.Run("reused refresh token revokes the token", func(t *testing.T) {
+		server := newTestServer(t)
+		token := server.IssueToken(testClientID, "123", moneybutton.PermissionsIdentity)
+		client := server.NewClient()
+
+		refreshed, err := client.RefreshAccessToken(context.Background(), testClientID, token.RefreshToken)
+		require.NoError(t, err)
+
+		_, err = client.RefreshAccessToken(context.Background(), testClientID, token.RefreshToken)
+		assert.ErrorIs(t, err, moneybutton.ErrInvalidRefreshToken)
+		var apiErr *moneybutton.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "Invalid grant: refresh token has already been used", apiErr.Errors[0].Detail)
+
+		// The legitimate client is logged out too
+		_, err = client.GetUserIdentity(context.Background(), refreshed.AccessToken)
+		assert.ErrorIs(t, err, moneybutton.ErrUnauthorized)
+		_, err = client.RefreshAccessToken(context.Background(), testClientID, refreshed.RefreshToken)
+		assert.ErrorIs(t, err, moneybutton.ErrInvalidRefreshToken)
+	})
+
+	t.Run("rotation disabled", func(t *testing.T) {
+		server := newTestServer(t)
+		server.SetRefreshTokenRotation(false)
+		token := server.IssueToken(testClientID, "123", moneybutton.PermissionsIdentity)
+		client := server.NewClient()
+
+		for i := 0; i < 2; i++ {
+			refreshed, err := client.RefreshAccessToken(context.Background(), testClientID, token.RefreshToken)
+			require.NoError(t, err)
+			assert.Equal(t, token.RefreshToken, refreshed.RefreshToken)
+		}
+	})
+
+	t.Run("refresh token expires", func(t *testing.T) {
+		server := newTestServer(t)
+		now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+		server.SetNow(func() time.Time { return now })
+		server.SetRefreshTokenLifetime(24 * time.Hour)
+		token := server.IssueToken(testClientID, "123", moneybutton.PermissionsIdentity)
+		client := server.NewClient()
+
+		stored, ok := server.Token(token.AccessToken)
+		require.True(t, ok)
+		assert.Equal(t, now.Add(24*time.Hour), stored.RefreshExpiresAt)
+
+		// Rotation issues a refresh token with a new lifetime
+		now = now.Add(23 * time.Hour)
+		refreshed, err := client.RefreshAccessToken(context.Background(), testClientID, token.RefreshToken)
+		require.NoError(t, err)
+
+		now = now.Add(24 * time.Hour)
+		_, err = client.RefreshAccessToken(context.Background(), testClientID, refreshed.RefreshToken)
+		var apiErr *moneybutton.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "Invalid grant: refresh token has expired", apiErr.Errors[0].Detail)
+		assert.ErrorIs(t, err, moneybutton.ErrInvalidRefreshToken)
+	})
+
+	t.Run("token source follows the rotation", func(t *testing.T) {
+		server := newOAuthServer(t)
+		client := server.NewClient()
+		query := authorize(t, server, nil, moneybutton.PermissionsIdentity)
+		token, err := client.GetRefreshToken(context.Background(), testClientID, query.Get("code"), testRedirectURI)
+		require.NoError(t, err)
+
+		source, err := client.NewTokenSource(testClientID, token)
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			current, err := source.Token(context.Background())
+			require.NoError(t, err)
+			server.ExpireAccessToken(current.AccessToken)
+			_, err = source.Refresh(context.Background())
+			require.NoError(t, err)
+		}
+
+		accessToken, err := source.AccessToken(context.Background())
+		require.NoError(t, err)
+		_, err = client.GetUserIdentity(context.Background(), accessToken)
+		require.NoError(t, err)
+	})
+}
+
+// ExampleServer_Authorize example using Authorize()
+func ExampleServer_Authorize() {
+	server := NewServer()
+	defer server.Close()
+
+	server.AddUser(&User{ID: "123", Name: "MrZ"})
+	server.AddApp(&App{ClientID: "my-client-id", RedirectURIs: []string{"https://example.com/callback"}})
+	server.SetConsent(&Consent{Approve: true, UserID: "123"})
+
+	client := server.NewClient()
+	pkce, _ := moneybutton.NewPKCE()
+	authURL, _ := client.GetAuthorizationURLWithPKCE(
+		"my-client-id", "https://example.com/callback",
+		moneybutton.Scopes{moneybutton.PermissionsIdentity}, "my-state", pkce,
+	)
+
+	// The user approves and is sent back with a code
+	redirect, err := server.Authorize(authURL)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	token, err := client.GetRefreshTokenWithPKCE(
+		context.Background(), "my-client-id", redirect.Query().Get("code"),
+		"https://example.com/callback", pkce.CodeVerifier,
+	)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("state: %s scope: %s", redirect.Query().Get("state"), token.Scope)
+	// Output:state: my-state scope: auth.user_identity:read
+}
@@ -0,0 +1,249 @@
+package moneybuttontest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/tonicpow/go-moneybutton"
+)
+
+// RecorderMode is whether a Recorder records or replays requests
+type RecorderMode int
+
+// Modes of the Recorder
+const (
+	ModeReplay RecorderMode = iota // Replay the fixture file (no requests are sent)
+	ModeRecord                     // Send the requests and record them to the fixture file
+)
+
+// Scrubbed is the value that replaces tokens and secrets in a fixture file
+const Scrubbed = "SCRUBBED"
+
+// ErrInteractionNotFound is returned on replay when a request was not recorded
+var ErrInteractionNotFound = errors.New("no recorded interaction for the request")
+
+// skipHeaders are the response headers that are never recorded (secrets or different on every request)
+var skipHeaders = []string{"Cookie", "Date", "Set-Cookie"}
+
+// Fixture is a recorded list of request/response pairs (the golden file)
+type Fixture struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded request and its response
+type Interaction struct {
+	Request  *RecordedRequest  `json:"request"`
+	Response *RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a recorded request (tokens and secrets are scrubbed)
+type RecordedRequest struct {
+	Body   string `json:"body,omitempty"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// RecordedResponse is a recorded response (tokens and secrets are scrubbed)
+//
+// JSON bodies are stored as JSON (readable in the golden file), every other body as text
+type RecordedResponse struct {
+	Body     json.RawMessage `json:"body,omitempty"`
+	BodyText string          `json:"body_text,omitempty"`
+	Header   http.Header     `json:"header,omitempty"`
+	Status   int             `json:"status"`
+}
+
+// Recorder wraps the HTTP client of a moneybutton.Client and records requests to a fixture
+// file or replays them from it (safe for concurrent use)
+//
+//	recorder, err := moneybuttontest.NewRecorder("testdata/identity.json", moneybuttontest.ModeReplay, nil)
+//	client.SetHTTPClient(recorder)
+//
+// Record against the live API by passing ModeRecord and the current client (client.HTTPClient()),
+// then call Save to write the golden file
+type Recorder struct {
+	filename    string
+	fixture     *Fixture
+	mode        RecorderMode
+	mu          sync.Mutex
+	next        moneybutton.HTTPInterface
+	scrubFields []string              // Extra fields (tokens and secrets are always scrubbed)
+	scrubber    *moneybutton.Redactor // Replaced when fields are added
+	used        []bool
+}
+
+// NewRecorder will create a new Recorder for the fixture file
+//
+// In ModeReplay the file is loaded (next is not used), in ModeRecord requests are sent to next
+func NewRecorder(filename string, mode RecorderMode, next moneybutton.HTTPInterface) (*Recorder, error) {
+
+	// Check required parameters
+	if len(filename) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "filename")
+	} else if mode == ModeRecord && next == nil {
+		return nil, fmt.Errorf("missing required parameter: %s", "next")
+	}
+
+	r := &Recorder{
+		filename: filename,
+		fixture:  &Fixture{},
+		mode:     mode,
+		next:     next,
+		scrubber: moneybutton.NewRedactor(Scrubbed),
+	}
+
+	if mode == ModeReplay {
+		data, err := ioutil.ReadFile(filename) //nolint:gosec // filename is supplied by the caller
+		if err != nil {
+			return nil, err
+		} else if err = json.Unmarshal(data, r.fixture); err != nil {
+			return nil, fmt.Errorf("invalid fixture file %s: %w", filename, err)
+		}
+		r.used = make([]bool, len(r.fixture.Interactions))
+	}
+	return r, nil
+}
+
+// ScrubFields will add form, query and JSON fields to scrub (besides tokens and secrets)
+//
+// Fields must be added before recording or replaying, requests are matched on the scrubbed values
+func (r *Recorder) ScrubFields(fields ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scrubFields = append(r.scrubFields, fields...)
+	r.scrubber = moneybutton.NewRedactor(Scrubbed, r.scrubFields...)
+}
+
+// Interactions will return the number of recorded (or loaded) interactions
+func (r *Recorder) Interactions() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.fixture.Interactions)
+}
+
+// Do will record or replay the request
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+
+	// Read the request body (and put it back for the next client)
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	scrubber := r.getScrubber()
+	recorded := &RecordedRequest{
+		Body:   scrubBody(scrubber, body),
+		Method: req.Method,
+		URL:    scrubber.URL(req.URL.String()),
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, recorded)
+}
+
+// Save will write the recorded interactions to the fixture file (only in ModeRecord)
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.filename, append(data, '\n'), 0o600)
+}
+
+// record will send the request and record the scrubbed response
+func (r *Recorder) record(req *http.Request, recorded *RecordedRequest) (*http.Response, error) {
+	resp, err := r.next.Do(req)
+	if err != nil {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	response := &RecordedResponse{Header: resp.Header.Clone(), Status: resp.StatusCode}
+	for _, header := range skipHeaders {
+		response.Header.Del(header)
+	}
+	if len(response.Header) == 0 {
+		response.Header = nil
+	}
+	if scrubbed, ok := r.getScrubber().JSON(body); ok {
+		response.Body = scrubbed
+	} else {
+		response.BodyText = string(body)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Interactions = append(r.fixture.Interactions, &Interaction{Request: recorded, Response: response})
+	return resp, nil
+}
+
+// replay will return the first unused interaction that matches the request
+func (r *Recorder) replay(req *http.Request, recorded *RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.fixture.Interactions {
+		if r.used[i] || !interaction.Request.matches(recorded) {
+			continue
+		}
+		r.used[i] = true
+
+		body := []byte(interaction.Response.BodyText)
+		if len(interaction.Response.Body) > 0 {
+			body = interaction.Response.Body
+		}
+		return &http.Response{
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Header:        interaction.Response.Header.Clone(),
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Request:       req,
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, recorded.Method, recorded.URL)
+}
+
+// matches will return true if the (scrubbed) requests are the same
+func (q *RecordedRequest) matches(other *RecordedRequest) bool {
+	return q.Method == other.Method && q.URL == other.URL && q.Body == other.Body
+}
+
+// getScrubber will return the Redactor for the scrubbed fields
+func (r *Recorder) getScrubber() *moneybutton.Redactor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.scrubber
+}
+
+// scrubBody will scrub a JSON or form body (a body that cannot be parsed is fully scrubbed)
+func scrubBody(scrubber *moneybutton.Redactor, body []byte) string {
+	if scrubbed, ok := scrubber.JSON(body); ok {
+		return string(scrubbed)
+	}
+	return scrubber.Form(string(body))
+}
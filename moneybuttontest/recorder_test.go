@@ -0,0 +1,234 @@
+package moneybuttontest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tonicpow/go-moneybutton"
+)
+
+// newReplayClient will return a production client that replays the fixture file
+func newReplayClient(t *testing.T, fixture string) (*moneybutton.Client, *Recorder) {
+	recorder, err := NewRecorder(filepath.Join("testdata", fixture), ModeReplay, nil)
+	require.NoError(t, err)
+	client := moneybutton.NewClient(nil, nil, nil)
+	client.SetHTTPClient(recorder)
+	return client, recorder
+}
+
+// TestNewRecorder tests the method NewRecorder()
+func TestNewRecorder(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing parameters", func(t *testing.T) {
+		_, err := NewRecorder("", ModeReplay, nil)
+		assert.Error(t, err)
+		_, err = NewRecorder(filepath.Join(t.TempDir(), "fixture.json"), ModeRecord, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("missing or invalid fixture file", func(t *testing.T) {
+		_, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), ModeReplay, nil)
+		assert.Error(t, err)
+
+		filename := filepath.Join(t.TempDir(), "invalid.json")
+		require.NoError(t, ioutil.WriteFile(filename, []byte("{"), 0o600))
+		_, err = NewRecorder(filename, ModeReplay, nil)
+		assert.ErrorContains(t, err, "invalid fixture file")
+	})
+
+	t.Run("fixture is loaded", func(t *testing.T) {
+		_, recorder := newReplayClient(t, "get_refresh_token.json")
+		assert.Equal(t, 2, recorder.Interactions())
+		assert.NoError(t, recorder.Save())
+	})
+}
+
+// TestRecorder_Replay tests the golden files decoded by the client
+func TestRecorder_Replay(t *testing.T) {
+	t.Parallel()
+
+	t.Run("get refresh token", func(t *testing.T) {
+		client, _ := newReplayClient(t, "get_refresh_token.json")
+		token, err := client.GetRefreshToken(context.Background(), "my-client-id", "a-code", "https://example.com/callback")
+		require.NoError(t, err)
+		assert.Equal(t, &moneybutton.RefreshTokenResponse{
+			AccessToken:  Scrubbed,
+			ExpiresIn:    3599,
+			RefreshToken: Scrubbed,
+			Scope:        "users.profiles:read auth.user_identity:read",
+			TokenType:    "Bearer",
+		}, token)
+
+		// The code is scrubbed, the second interaction is the expired code
+		_, err = client.GetRefreshToken(context.Background(), "my-client-id", "another-code", "https://example.com/callback")
+		assert.ErrorIs(t, err, moneybutton.ErrAuthCodeExpired)
+		var apiErr *moneybutton.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, []string{"ffb71830-409b-11eb-9032-37efc953c879"}, apiErr.ErrorIDs())
+	})
+
+	t.Run("refresh access token", func(t *testing.T) {
+		client, _ := newReplayClient(t, "refresh_access_token.json")
+		token, err := client.RefreshAccessToken(context.Background(), "my-client-id", "a-refresh-token")
+		require.NoError(t, err)
+		assert.Equal(t, uint32(3600), token.ExpiresIn)
+		assert.Equal(t, "Bearer", token.TokenType)
+		assert.Equal(t, Scrubbed, token.RefreshToken)
+	})
+
+	t.Run("user identity", func(t *testing.T) {
+		client, _ := newReplayClient(t, "user_identity.json")
+		identity, err := client.GetUserIdentity(context.Background(), "an-access-token")
+		require.NoError(t, err)
+		assert.Equal(t, "user_identities", identity.Data.Type)
+		assert.Equal(t, &moneybutton.Identity{ID: "123", Name: "MrZ"}, identity.Identity())
+		assert.Equal(t, "1.0", identity.JSONAPI.Version)
+	})
+
+	t.Run("user profile", func(t *testing.T) {
+		client, _ := newReplayClient(t, "user_profile.json")
+		profile, err := client.GetProfile(context.Background(), "123", "an-access-token")
+		require.NoError(t, err)
+		assert.Equal(t, "profiles", profile.Data.Type)
+		assert.Equal(t, "123", profile.UserID())
+
+		attributes := profile.Profile()
+		require.NotNil(t, attributes)
+		assert.Equal(t, "https://www.gravatar.com/avatar/372bc0ab9b8a8930d4a86b2c5b11f11e?d=identicon", attributes.AvatarURL.String())
+		assert.Equal(t, "I like Money Button.", attributes.Bio)
+		assert.Equal(t, time.Date(2019, 3, 26, 17, 33, 42, 788000000, time.UTC), attributes.CreatedAt)
+		assert.Equal(t, "USD", attributes.DefaultCurrency)
+		assert.Equal(t, "en", attributes.DefaultLanguage)
+		assert.Equal(t, "MrZ", attributes.Name)
+		assert.Equal(t, "mrz", attributes.PrimaryPaymail.Alias())
+	})
+
+	t.Run("interactions are used once", func(t *testing.T) {
+		client, _ := newReplayClient(t, "user_identity.json")
+		_, err := client.GetUserIdentity(context.Background(), "an-access-token")
+		require.NoError(t, err)
+		_, err = client.GetUserIdentity(context.Background(), "an-access-token")
+		assert.ErrorIs(t, err, ErrInteractionNotFound)
+	})
+
+	t.Run("request was not recorded", func(t *testing.T) {
+		client, _ := newReplayClient(t, "user_profile.json")
+		_, err := client.GetProfile(context.Background(), "456", "an-access-token")
+		assert.ErrorIs(t, err, ErrInteractionNotFound)
+		assert.ErrorContains(t, err, "GET https://www.moneybutton.com/api/v1/users/456/profile")
+	})
+}
+
+// TestRecorder_Record tests recording against the fake server and replaying the recording
+func TestRecorder_Record(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	code := server.AddCode(&Code{
+		ClientID: testClientID, RedirectURI: testRedirectURI, Scopes: allScopes, UserID: "123",
+	})
+
+	// Record
+	filename := filepath.Join(t.TempDir(), "recording.json")
+	client := server.NewClient()
+	recorder, err := NewRecorder(filename, ModeRecord, client.HTTPClient())
+	require.NoError(t, err)
+	client.SetHTTPClient(recorder)
+
+	token, err := client.GetRefreshToken(context.Background(), testClientID, code, testRedirectURI)
+	require.NoError(t, err)
+	recorded, err := client.GetUserIdentity(context.Background(), token.AccessToken)
+	require.NoError(t, err)
+	_, err = client.GetProfile(context.Background(), "999", token.AccessToken)
+	require.Error(t, err)
+	assert.Equal(t, 3, recorder.Interactions())
+	require.NoError(t, recorder.Save())
+
+	// Tokens and secrets are scrubbed
+	data, err := ioutil.ReadFile(filename) //nolint:gosec // test file
+	require.NoError(t, err)
+	for _, secret := range []string{code, token.AccessToken, token.RefreshToken, "Bearer " + token.AccessToken} {
+		assert.NotContains(t, string(data), secret)
+	}
+	assert.Equal(t, 3, strings.Count(string(data), `"SCRUBBED"`)+strings.Count(string(data), "code=SCRUBBED"))
+	assert.NotContains(t, string(data), `"Date"`)
+
+	// Replay (the server is not used)
+	replayClient := moneybutton.NewClient(nil, nil, server.Environment())
+	replayer, err := NewRecorder(filename, ModeReplay, nil)
+	require.NoError(t, err)
+	replayClient.SetHTTPClient(replayer)
+	requestsBefore := server.Requests(EndpointUserIdentity)
+
+	replayed, err := replayClient.GetRefreshToken(context.Background(), testClientID, "another-code", testRedirectURI)
+	require.NoError(t, err)
+	assert.Equal(t, Scrubbed, replayed.AccessToken)
+	assert.Equal(t, token.Scope, replayed.Scope)
+
+	identity, err := replayClient.GetUserIdentity(context.Background(), "another-token")
+	require.NoError(t, err)
+	assert.Equal(t, recorded.Identity(), identity.Identity())
+	assert.Equal(t, requestsBefore, server.Requests(EndpointUserIdentity))
+
+	_, err = replayClient.GetProfile(context.Background(), "999", "another-token")
+	var apiErr *moneybutton.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+// TestRecorder_ScrubFields tests the method ScrubFields()
+func TestRecorder_ScrubFields(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	token := server.IssueToken(testClientID, "123", allScopes...)
+	client := server.NewClient()
+
+	filename := filepath.Join(t.TempDir(), "recording.json")
+	recorder, err := NewRecorder(filename, ModeRecord, client.HTTPClient())
+	require.NoError(t, err)
+	recorder.ScrubFields("client_id", "primary-paymail")
+	client.SetHTTPClient(recorder)
+
+	refreshed, err := client.RefreshAccessToken(context.Background(), testClientID, token.RefreshToken)
+	require.NoError(t, err)
+	_, err = client.GetProfile(context.Background(), "123", refreshed.AccessToken)
+	require.NoError(t, err)
+	require.NoError(t, recorder.Save())
+
+	data, err := ioutil.ReadFile(filename) //nolint:gosec // test file
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "client_id=SCRUBBED")
+	assert.NotContains(t, string(data), testClientID)
+	assert.NotContains(t, string(data), "mrz@moneybutton.com")
+	assert.Contains(t, string(data), `"name": "MrZ"`)
+}
+
+// ExampleRecorder example using NewRecorder() to replay a golden file
+func ExampleRecorder() {
+	recorder, err := NewRecorder("testdata/user_identity.json", ModeReplay, nil)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	client := moneybutton.NewClient(nil, nil, nil)
+	client.SetHTTPClient(recorder)
+
+	identity, err := client.GetUserIdentity(context.Background(), "my-access-token")
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("user: %s", identity.Identity().Name)
+	// Output:user: MrZ
+}
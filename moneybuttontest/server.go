@@ -0,0 +1,423 @@
+// Package moneybuttontest is a local fake of the MoneyButton API for integration tests
+//
+// The Server implements the oAuth authorization and token endpoints and the user
+// identity, profile, balance and payment endpoints on top of a scriptable in-memory
+// state (apps, consent, users, codes, tokens, expiries and injected errors).
+// Point a client at it with:
+//
+//	server := moneybuttontest.NewServer()
+//	defer server.Close()
+//	client := moneybutton.NewClient(nil, nil, server.Environment())
+//
+// The Recorder records real requests to golden files (tokens and secrets are scrubbed)
+// and replays them in tests (see: NewRecorder)
+package moneybuttontest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/tonicpow/go-moneybutton"
+)
+
+// Endpoint is a fake MoneyButton endpoint (used for injecting errors)
+type Endpoint string
+
+// Endpoints of the fake server
+const (
+	EndpointAuthorize    Endpoint = "authorize"
+	EndpointPayment      Endpoint = "payment"
+	EndpointPayments     Endpoint = "payments"
+	EndpointToken        Endpoint = "token"
+	EndpointUserBalance  Endpoint = "user-balance"
+	EndpointUserIdentity Endpoint = "user-identity"
+	EndpointUserProfile  Endpoint = "user-profile"
+)
+
+// Default settings of the fake server
+const (
+	DefaultCodeLifetime  = 10 * time.Minute // How long an authorization code is valid
+	DefaultTokenLifetime = time.Hour        // How long an access token is valid
+)
+
+// User is a MoneyButton user (identity, profile and balance)
+type User struct {
+	AvatarURL       string    // IE: https://www.gravatar.com/avatar/123
+	Balance         Balance   // Balance of the user
+	Bio             string    // IE: I like Money Button.
+	CreatedAt       time.Time // When the user was created
+	DefaultCurrency string    // IE: USD
+	DefaultLanguage string    // IE: en
+	ID              string    // User ID (required)
+	Name            string    // Display name
+	PrimaryPaymail  string    // IE: mrz@moneybutton.com
+}
+
+// Balance is the balance of a user
+type Balance struct {
+	Amount   string // Amount in the currency (IE: 12.34)
+	Currency string // IE: USD
+	Satoshis int64  // Amount in satoshis
+}
+
+// Payment is a payment made by a user
+type Payment struct {
+	Amount     string                    // Amount in the currency (IE: 0.01)
+	AmountUSD  string                    // Amount in USD
+	ButtonData string                    // Custom data set on the button
+	ButtonID   string                    // Custom ID set on the button
+	CreatedAt  time.Time                 // When the payment was created (default is now)
+	Currency   string                    // IE: USD
+	ID         string                    // Payment ID (required)
+	Satoshis   string                    // Amount in satoshis
+	Status     moneybutton.PaymentStatus // IE: COMPLETED
+	TxID       string                    // Transaction ID
+	UpdatedAt  time.Time                 // When the payment was last updated (default is now)
+	UserID     string                    // The user that made the payment (the owner in filter[owner-id], required)
+}
+
+// Code is an authorization code that can be exchanged for a token
+type Code struct {
+	ClientID            string    // Client ID the code was issued to (required)
+	Code                string    // The code (generated if empty)
+	CodeChallenge       string    // PKCE code challenge (the code_verifier is required if set)
+	CodeChallengeMethod string    // PKCE code challenge method (IE: S256 or plain)
+	ExpiresAt           time.Time // When the code expires (default is DefaultCodeLifetime from now)
+	RedirectURI         string    // Redirect URI the code was issued for (required)
+	Scopes              []string  // Scopes granted to the token
+	State               string    // State of the authorization request
+	UserID              string    // User that authorized the client (required)
+}
+
+// Token is an issued access token and its refresh token
+type Token struct {
+	AccessToken      string    // Access token (used as the Bearer token)
+	ClientID         string    // Client ID the token was issued to
+	ExpiresAt        time.Time // When the access token expires
+	RefreshExpiresAt time.Time // When the refresh token expires (zero never expires)
+	RefreshToken     string    // Refresh token (used with RefreshAccessToken)
+	Scopes           []string  // Scopes granted to the token
+	UserID           string    // User that authorized the client
+}
+
+// failure is an injected error or raw response
+type failure struct {
+	body   string // Raw body (instead of an error with the detail)
+	detail string
+	status int
+}
+
+// Server is a fake MoneyButton API server (safe for concurrent use)
+type Server struct {
+	apps                 map[string]*App
+	codes                map[string]*Code
+	consent              *Consent
+	failures             map[Endpoint][]*failure
+	mu                   sync.Mutex
+	now                  func() time.Time
+	payments             map[string]*Payment
+	refreshTokenLifetime time.Duration
+	refreshTokens        map[string]*Token // by refresh token
+	requests             map[Endpoint]int
+	rotateRefreshTokens  bool
+	rotatedTokens        map[string]*Token // by rotated (used) refresh token
+	server               *httptest.Server
+	tokenLifetime        time.Duration
+	tokens               map[string]*Token // by access token
+	usedCodes            map[string]*Token // by used authorization code
+	users                map[string]*User
+}
+
+// NewServer will start a new fake MoneyButton server (call Close when done)
+func NewServer() *Server {
+	s := &Server{
+		apps:                make(map[string]*App),
+		codes:               make(map[string]*Code),
+		failures:            make(map[Endpoint][]*failure),
+		now:                 time.Now,
+		payments:            make(map[string]*Payment),
+		refreshTokens:       make(map[string]*Token),
+		requests:            make(map[Endpoint]int),
+		rotateRefreshTokens: true,
+		rotatedTokens:       make(map[string]*Token),
+		tokenLifetime:       DefaultTokenLifetime,
+		tokens:              make(map[string]*Token),
+		usedCodes:           make(map[string]*Token),
+		users:               make(map[string]*User),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(oauthPath+"authorize", s.handleAuthorize)
+	mux.HandleFunc(oauthPath+"token", s.handleToken)
+	mux.HandleFunc(apiPath, s.handleAPI)
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+// Close will shut down the server
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// URL will return the base URL of the server (IE: http://127.0.0.1:1234)
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Environment will return the environment for moneybutton.NewClient()
+func (s *Server) Environment() *moneybutton.Environment {
+	return &moneybutton.Environment{
+		APIURL:      s.server.URL + apiPath,
+		ClientURL:   s.server.URL + "/",
+		Environment: "moneybuttontest",
+		OauthURL:    s.server.URL + oauthPath,
+	}
+}
+
+// NewClient will return a new client using the server environment
+func (s *Server) NewClient() *moneybutton.Client {
+	return moneybutton.NewClient(nil, nil, s.Environment())
+}
+
+// SetNow will change the clock of the server (useful for testing expiries)
+func (s *Server) SetNow(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// SetTokenLifetime will change how long new access tokens are valid
+func (s *Server) SetTokenLifetime(lifetime time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenLifetime = lifetime
+}
+
+// SetRefreshTokenLifetime will change how long new refresh tokens are valid (zero never expires)
+func (s *Server) SetRefreshTokenLifetime(lifetime time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshTokenLifetime = lifetime
+}
+
+// SetRefreshTokenRotation will change if a new refresh token is issued on every refresh (default is true)
+//
+// With rotation, reusing a rotated refresh token revokes the token (reuse detection)
+func (s *Server) SetRefreshTokenRotation(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotateRefreshTokens = enabled
+}
+
+// AddUser will add (or replace) the user
+func (s *Server) AddUser(user *User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	userCopy := *user
+	if userCopy.CreatedAt.IsZero() {
+		userCopy.CreatedAt = s.now()
+	}
+	s.users[user.ID] = &userCopy
+}
+
+// SetBalance will change the balance of the user
+func (s *Server) SetBalance(userID string, balance Balance) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user, ok := s.users[userID]; ok {
+		user.Balance = balance
+	}
+}
+
+// AddPayment will add (or replace) the payment
+func (s *Server) AddPayment(payment *Payment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paymentCopy := *payment
+	if paymentCopy.CreatedAt.IsZero() {
+		paymentCopy.CreatedAt = s.now()
+	}
+	if paymentCopy.UpdatedAt.IsZero() {
+		paymentCopy.UpdatedAt = paymentCopy.CreatedAt
+	}
+	s.payments[payment.ID] = &paymentCopy
+}
+
+// SetPaymentStatus will change the status of the payment
+func (s *Server) SetPaymentStatus(paymentID string, status moneybutton.PaymentStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if payment, ok := s.payments[paymentID]; ok {
+		payment.Status = status
+		payment.UpdatedAt = s.now()
+	}
+}
+
+// AddCode will add an authorization code and return it (the code is generated if empty)
+func (s *Server) AddCode(code *Code) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	codeCopy := *code
+	if len(codeCopy.Code) == 0 {
+		codeCopy.Code = randomID()
+	}
+	if codeCopy.ExpiresAt.IsZero() {
+		codeCopy.ExpiresAt = s.now().Add(DefaultCodeLifetime)
+	}
+	s.codes[codeCopy.Code] = &codeCopy
+	return codeCopy.Code
+}
+
+// IssueToken will issue a new token for the user (as if the user authorized the client)
+func (s *Server) IssueToken(clientID, userID string, scopes ...string) *moneybutton.RefreshTokenResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issueToken(clientID, userID, scopes).response(s.now())
+}
+
+// Token will return a copy of the token for the access token (false if not found)
+func (s *Server) Token(accessToken string) (Token, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[accessToken]
+	if !ok {
+		return Token{}, false
+	}
+	return *token, true
+}
+
+// ExpireAccessToken will expire the access token (the refresh token can still be used)
+func (s *Server) ExpireAccessToken(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token, ok := s.tokens[accessToken]; ok {
+		token.ExpiresAt = s.now().Add(-time.Second)
+	}
+}
+
+// RevokeToken will revoke the access token and its refresh token
+func (s *Server) RevokeToken(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token, ok := s.tokens[accessToken]; ok {
+		s.revoke(token)
+	}
+}
+
+// FailNext will make the next request to the endpoint fail with the status and detail
+//
+// Calling it more than once will fail that many requests (in order)
+func (s *Server) FailNext(endpoint Endpoint, status int, detail string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[endpoint] = append(s.failures[endpoint], &failure{detail: detail, status: status})
+}
+
+// RespondNext will make the next request to the endpoint return the status and raw body
+// (IE: a response without data)
+//
+// It is queued with the FailNext errors of the endpoint (in order)
+func (s *Server) RespondNext(endpoint Endpoint, status int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[endpoint] = append(s.failures[endpoint], &failure{body: body, status: status})
+}
+
+// Requests will return the number of requests received by the endpoint
+func (s *Server) Requests(endpoint Endpoint) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+// issueToken will create and store a new token (must hold the lock)
+func (s *Server) issueToken(clientID, userID string, scopes []string) *Token {
+	token := &Token{
+		AccessToken:      randomID(),
+		ClientID:         clientID,
+		ExpiresAt:        s.now().Add(s.tokenLifetime),
+		RefreshExpiresAt: s.refreshExpiry(),
+		RefreshToken:     randomID(),
+		Scopes:           append([]string(nil), scopes...),
+		UserID:           userID,
+	}
+	s.tokens[token.AccessToken] = token
+	s.refreshTokens[token.RefreshToken] = token
+	return token
+}
+
+// refreshExpiry will return when a new refresh token expires (must hold the lock)
+func (s *Server) refreshExpiry() time.Time {
+	if s.refreshTokenLifetime <= 0 {
+		return time.Time{}
+	}
+	return s.now().Add(s.refreshTokenLifetime)
+}
+
+// revoke will remove the access token and refresh token (must hold the lock)
+func (s *Server) revoke(token *Token) {
+	delete(s.tokens, token.AccessToken)
+	delete(s.refreshTokens, token.RefreshToken)
+}
+
+// response will return the token as a token endpoint response
+func (t *Token) response(now time.Time) *moneybutton.RefreshTokenResponse {
+	expiresIn := t.ExpiresAt.Sub(now).Round(time.Second) / time.Second
+	if expiresIn < 0 {
+		expiresIn = 0
+	}
+	return &moneybutton.RefreshTokenResponse{
+		AccessToken:  t.AccessToken,
+		ExpiresIn:    uint32(expiresIn),
+		RefreshToken: t.RefreshToken,
+		Scope:        moneybutton.Scopes(t.Scopes).String(),
+		TokenType:    "Bearer",
+	}
+}
+
+// hasScope will return true if the token was granted the scope
+func (t *Token) hasScope(scope string) bool {
+	for _, granted := range t.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// nextFailure will record the request and return the next injected error (if any)
+func (s *Server) nextFailure(endpoint Endpoint) *failure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[endpoint]++
+	queue := s.failures[endpoint]
+	if len(queue) == 0 {
+		return nil
+	}
+	s.failures[endpoint] = queue[1:]
+	return queue[0]
+}
+
+// write will write the injected response
+func (f *failure) write(w http.ResponseWriter) {
+	if len(f.body) == 0 {
+		writeError(w, f.status, f.detail)
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(f.status)
+	_, _ = w.Write([]byte(f.body))
+}
+
+// randomID will return a random hex ID
+func randomID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
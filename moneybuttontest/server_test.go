@@ -0,0 +1,250 @@
+package moneybuttontest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tonicpow/go-moneybutton"
+)
+
+// testClientID is the client ID used in tests
+const testClientID = "test-client"
+
+// newTestServer will start a server with a single user (closed when the test ends)
+func newTestServer(t *testing.T) *Server {
+	server := NewServer()
+	t.Cleanup(server.Close)
+	server.AddUser(&User{
+		AvatarURL:       "https://www.gravatar.com/avatar/123",
+		Balance:         Balance{Amount: "12.34", Currency: "USD", Satoshis: 5000000},
+		Bio:             "I like Money Button.",
+		CreatedAt:       time.Date(2019, 3, 26, 17, 33, 42, 788000000, time.UTC),
+		DefaultCurrency: "USD",
+		DefaultLanguage: "en",
+		ID:              "123",
+		Name:            "MrZ",
+		PrimaryPaymail:  "mrz@moneybutton.com",
+	})
+	return server
+}
+
+// TestNewServer tests the method NewServer()
+func TestNewServer(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	assert.True(t, strings.HasPrefix(server.URL(), "http://"))
+
+	env := server.Environment()
+	assert.Equal(t, server.URL()+"/api/v1/", env.APIURL)
+	assert.Equal(t, server.URL()+"/oauth/v1/", env.OauthURL)
+	assert.Equal(t, server.URL()+"/", env.ClientURL)
+
+	client := server.NewClient()
+	require.NotNil(t, client)
+	assert.Equal(t, env.APIURL, client.Environment.APIURL)
+}
+
+// TestServer_IssueToken tests the methods IssueToken(), Token(), ExpireAccessToken() and RevokeToken()
+func TestServer_IssueToken(t *testing.T) {
+	t.Parallel()
+
+	t.Run("issued token can be used", func(t *testing.T) {
+		server := newTestServer(t)
+		token := server.IssueToken(testClientID, "123", moneybutton.PermissionsIdentity)
+		assert.Equal(t, "Bearer", token.TokenType)
+		assert.Equal(t, uint32(DefaultTokenLifetime/time.Second), token.ExpiresIn)
+		assert.Equal(t, moneybutton.PermissionsIdentity, token.Scope)
+
+		stored, ok := server.Token(token.AccessToken)
+		require.True(t, ok)
+		assert.Equal(t, "123", stored.UserID)
+		assert.Equal(t, testClientID, stored.ClientID)
+
+		identity, err := server.NewClient().GetUserIdentity(context.Background(), token.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "123", identity.UserID())
+	})
+
+	t.Run("expired access token", func(t *testing.T) {
+		server := newTestServer(t)
+		token := server.IssueToken(testClientID, "123", moneybutton.PermissionsIdentity)
+		server.ExpireAccessToken(token.AccessToken)
+
+		_, err := server.NewClient().GetUserIdentity(context.Background(), token.AccessToken)
+		assert.ErrorIs(t, err, moneybutton.ErrUnauthorized)
+	})
+
+	t.Run("clock and token lifetime", func(t *testing.T) {
+		server := newTestServer(t)
+		now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+		server.SetNow(func() time.Time { return now })
+		server.SetTokenLifetime(time.Minute)
+
+		token := server.IssueToken(testClientID, "123", moneybutton.PermissionsIdentity)
+		assert.Equal(t, uint32(60), token.ExpiresIn)
+
+		now = now.Add(time.Minute)
+		_, err := server.NewClient().GetUserIdentity(context.Background(), token.AccessToken)
+		assert.ErrorIs(t, err, moneybutton.ErrUnauthorized)
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		server := newTestServer(t)
+		token := server.IssueToken(testClientID, "123", moneybutton.PermissionsIdentity)
+		server.RevokeToken(token.AccessToken)
+		_, ok := server.Token(token.AccessToken)
+		assert.False(t, ok)
+
+		client := server.NewClient()
+		_, err := client.GetUserIdentity(context.Background(), token.AccessToken)
+		assert.ErrorIs(t, err, moneybutton.ErrUnauthorized)
+		_, err = client.RefreshAccessToken(context.Background(), testClientID, token.RefreshToken)
+		assert.ErrorIs(t, err, moneybutton.ErrInvalidRefreshToken)
+	})
+}
+
+// TestServer_FailNext tests the methods FailNext() and Requests()
+func TestServer_FailNext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("errors are returned in order", func(t *testing.T) {
+		server := newTestServer(t)
+		token := server.IssueToken(testClientID, "123", moneybutton.PermissionsIdentity)
+		client := server.NewClient()
+
+		server.FailNext(EndpointUserIdentity, http.StatusBadRequest, "Invalid request")
+		server.FailNext(EndpointUserIdentity, http.StatusForbidden, "Forbidden: insufficient scope")
+
+		_, err := client.GetUserIdentity(context.Background(), token.AccessToken)
+		var apiErr *moneybutton.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		require.Len(t, apiErr.Errors, 1)
+		assert.Equal(t, "Invalid request", apiErr.Errors[0].Detail)
+		assert.NotEmpty(t, apiErr.ErrorIDs())
+
+		_, err = client.GetUserIdentity(context.Background(), token.AccessToken)
+		assert.ErrorIs(t, err, moneybutton.ErrInsufficientScope)
+
+		// The failures are used up
+		_, err = client.GetUserIdentity(context.Background(), token.AccessToken)
+		require.NoError(t, err)
+
+		// Raw responses are queued the same way
+		server.RespondNext(EndpointUserIdentity, http.StatusOK, `{"data":{"type":"user_identities","id":"123"}}`)
+		identity, err := client.GetUserIdentity(context.Background(), token.AccessToken)
+		require.NoError(t, err)
+		assert.Nil(t, identity.Identity())
+		assert.Equal(t, 4, server.Requests(EndpointUserIdentity))
+		assert.Equal(t, 0, server.Requests(EndpointUserProfile))
+	})
+
+	t.Run("server errors are retried by the client", func(t *testing.T) {
+		server := newTestServer(t)
+		token := server.IssueToken(testClientID, "123", moneybutton.PermissionsIdentity)
+		client := server.NewClient()
+
+		server.FailNext(EndpointToken, http.StatusServiceUnavailable, "Maintenance")
+		_, err := client.RefreshAccessToken(context.Background(), testClientID, token.RefreshToken)
+		require.NoError(t, err)
+		assert.Equal(t, 2, server.Requests(EndpointToken))
+	})
+}
+
+// TestServer_GetRefreshToken tests exchanging an authorization code added with AddCode()
+func TestServer_GetRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	const redirectURI = "https://example.com/callback"
+
+	t.Run("valid code", func(t *testing.T) {
+		server := newTestServer(t)
+		code := server.AddCode(&Code{
+			ClientID: testClientID, RedirectURI: redirectURI, Scopes: []string{moneybutton.PermissionsIdentity}, UserID: "123",
+		})
+
+		token, err := server.NewClient().GetRefreshToken(context.Background(), testClientID, code, redirectURI)
+		require.NoError(t, err)
+		assert.NotEmpty(t, token.AccessToken)
+		assert.NotEmpty(t, token.RefreshToken)
+		assert.Equal(t, moneybutton.PermissionsIdentity, token.Scope)
+	})
+
+	t.Run("invalid codes", func(t *testing.T) {
+		server := newTestServer(t)
+		now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+		server.SetNow(func() time.Time { return now })
+		client := server.NewClient()
+
+		expired := server.AddCode(&Code{ClientID: testClientID, ExpiresAt: now, RedirectURI: redirectURI, UserID: "123"})
+		otherClient := server.AddCode(&Code{ClientID: "other", RedirectURI: redirectURI, UserID: "123"})
+		otherRedirect := server.AddCode(&Code{ClientID: testClientID, RedirectURI: "https://other.com", UserID: "123"})
+
+		for _, code := range []string{"unknown", expired, otherClient, otherRedirect} {
+			_, err := client.GetRefreshToken(context.Background(), testClientID, code, redirectURI)
+			assert.ErrorIs(t, err, moneybutton.ErrAuthCodeExpired, code)
+		}
+	})
+
+	t.Run("code is single use", func(t *testing.T) {
+		server := newTestServer(t)
+		code := server.AddCode(&Code{Code: "abc", ClientID: testClientID, RedirectURI: redirectURI, UserID: "123"})
+		assert.Equal(t, "abc", code)
+		client := server.NewClient()
+
+		_, err := client.GetRefreshToken(context.Background(), testClientID, code, redirectURI)
+		require.NoError(t, err)
+		_, err = client.GetRefreshToken(context.Background(), testClientID, code, redirectURI)
+		assert.ErrorIs(t, err, moneybutton.ErrAuthCodeExpired)
+	})
+}
+
+// TestServer_RefreshAccessToken tests refreshing an access token
+func TestServer_RefreshAccessToken(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	token := server.IssueToken(testClientID, "123", moneybutton.PermissionsIdentity)
+	client := server.NewClient()
+
+	_, err := client.RefreshAccessToken(context.Background(), "other", token.RefreshToken)
+	assert.ErrorIs(t, err, moneybutton.ErrInvalidRefreshToken)
+	_, err = client.RefreshAccessToken(context.Background(), testClientID, "unknown")
+	assert.ErrorIs(t, err, moneybutton.ErrInvalidRefreshToken)
+
+	refreshed, err := client.RefreshAccessToken(context.Background(), testClientID, token.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, token.AccessToken, refreshed.AccessToken)
+	assert.NotEqual(t, token.RefreshToken, refreshed.RefreshToken)
+
+	// The old access token is replaced
+	_, err = client.GetUserIdentity(context.Background(), token.AccessToken)
+	assert.ErrorIs(t, err, moneybutton.ErrUnauthorized)
+	_, err = client.GetUserIdentity(context.Background(), refreshed.AccessToken)
+	assert.NoError(t, err)
+}
+
+// ExampleNewServer example using NewServer()
+func ExampleNewServer() {
+	server := NewServer()
+	defer server.Close()
+
+	server.AddUser(&User{ID: "123", Name: "MrZ"})
+	token := server.IssueToken("my-client-id", "123", moneybutton.PermissionsIdentity)
+
+	client := moneybutton.NewClient(nil, nil, server.Environment())
+	identity, err := client.GetUserIdentity(context.Background(), token.AccessToken)
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("user: %s", identity.Identity().Name)
+	// Output:user: MrZ
+}
@@ -0,0 +1,104 @@
+package moneybutton
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Paymail is a paymail address (IE: mrz@moneybutton.com)
+//
+// Use ParsePaymail() to create a validated paymail, a decoded paymail is not validated (see: Valid())
+//
+// Specs: https://bsvalias.org/02-01-paymail-address.html
+type Paymail string
+
+// ParsePaymail will validate and return the paymail address
+func ParsePaymail(address string) (Paymail, error) {
+	address = strings.TrimSpace(address)
+	alias, domain, ok := strings.Cut(address, "@")
+	if !ok || len(alias) == 0 || len(domain) == 0 {
+		return "", fmt.Errorf("invalid paymail: %q", address)
+	}
+	if !validPaymailAlias(alias) {
+		return "", fmt.Errorf("invalid paymail alias: %q", address)
+	}
+	if !validPaymailDomain(domain) {
+		return "", fmt.Errorf("invalid paymail domain: %q", address)
+	}
+	return Paymail(address), nil
+}
+
+// Alias will return the alias (the part before the @)
+func (p Paymail) Alias() string {
+	alias, _, _ := strings.Cut(string(p), "@")
+	return alias
+}
+
+// Domain will return the domain (the part after the @)
+func (p Paymail) Domain() string {
+	_, domain, _ := strings.Cut(string(p), "@")
+	return domain
+}
+
+// Valid will return true if the paymail address is valid (see: ParsePaymail())
+func (p Paymail) Valid() bool {
+	paymail, err := ParsePaymail(string(p))
+	return err == nil && paymail == p
+}
+
+// String will return the paymail address
+func (p Paymail) String() string {
+	return string(p)
+}
+
+// UnmarshalJSON will decode the paymail as-is (null is empty)
+//
+// The paymail is not validated, one bad value must not fail the whole response (see: Valid())
+func (p *Paymail) UnmarshalJSON(data []byte) error {
+	var address *string
+	if err := json.Unmarshal(data, &address); err != nil {
+		return err
+	}
+	if address == nil {
+		*p = ""
+		return nil
+	}
+	*p = Paymail(*address)
+	return nil
+}
+
+// validPaymailAlias will check the alias (letters, digits, dot, dash, underscore and plus)
+func validPaymailAlias(alias string) bool {
+	for _, r := range alias {
+		if !isAlphaNumeric(r) && !strings.ContainsRune(".-_+", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// validPaymailDomain will check the domain is a host name with at least two labels
+func validPaymailDomain(domain string) bool {
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 ||
+			strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if !isAlphaNumeric(r) && r != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isAlphaNumeric will return true for ASCII letters and digits
+func isAlphaNumeric(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
@@ -0,0 +1,95 @@
+package moneybutton
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParsePaymail tests the method ParsePaymail()
+func TestParsePaymail(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid paymails", func(t *testing.T) {
+		for _, address := range []string{
+			"mrz@moneybutton.com",
+			"first.last+tag@sub.example.io",
+			" 123_abc-def@my-domain.co.uk ",
+		} {
+			paymail, err := ParsePaymail(address)
+			assert.NoError(t, err, address)
+			assert.NotEmpty(t, paymail.Alias(), address)
+			assert.NotEmpty(t, paymail.Domain(), address)
+		}
+	})
+
+	t.Run("invalid paymails", func(t *testing.T) {
+		for _, address := range []string{
+			"",
+			"mrz",
+			"@moneybutton.com",
+			"mrz@",
+			"mrz@moneybutton",
+			"mr z@moneybutton.com",
+			"mrz@money@button.com",
+			"mrz@-moneybutton.com",
+			"mrz@moneybutton..com",
+		} {
+			paymail, err := ParsePaymail(address)
+			assert.Error(t, err, address)
+			assert.Equal(t, Paymail(""), paymail)
+		}
+	})
+
+	t.Run("alias and domain", func(t *testing.T) {
+		paymail, err := ParsePaymail("mrz@moneybutton.com")
+		require.NoError(t, err)
+		assert.Equal(t, "mrz", paymail.Alias())
+		assert.Equal(t, "moneybutton.com", paymail.Domain())
+		assert.Equal(t, "mrz@moneybutton.com", paymail.String())
+	})
+}
+
+// TestPaymail_UnmarshalJSON tests the method UnmarshalJSON()
+func TestPaymail_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	var paymail Paymail
+	require.NoError(t, json.Unmarshal([]byte(`"mrz@moneybutton.com"`), &paymail))
+	assert.Equal(t, Paymail("mrz@moneybutton.com"), paymail)
+
+	require.NoError(t, json.Unmarshal([]byte(`null`), &paymail))
+	assert.Equal(t, Paymail(""), paymail)
+
+	// Invalid paymails are decoded (and can be checked with Valid())
+	require.NoError(t, json.Unmarshal([]byte(`"mrz"`), &paymail))
+	assert.Equal(t, Paymail("mrz"), paymail)
+	assert.False(t, paymail.Valid())
+
+	assert.Error(t, json.Unmarshal([]byte(`123`), &paymail))
+}
+
+// TestPaymail_Valid tests the method Valid()
+func TestPaymail_Valid(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, Paymail("mrz@moneybutton.com").Valid())
+	assert.False(t, Paymail("").Valid())
+	assert.False(t, Paymail("mrz").Valid())
+	assert.False(t, Paymail("mrz@localhost").Valid())
+	assert.False(t, Paymail(" mrz@moneybutton.com ").Valid())
+}
+
+// ExampleParsePaymail example using ParsePaymail()
+func ExampleParsePaymail() {
+	paymail, err := ParsePaymail("mrz@moneybutton.com")
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+	fmt.Printf("alias: %s domain: %s", paymail.Alias(), paymail.Domain())
+	// Output:alias: mrz domain: moneybutton.com
+}
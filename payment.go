@@ -0,0 +1,123 @@
+package moneybutton
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+/*
+{
+  "data": {
+    "type": "payments",
+    "id": "1040",
+    "attributes": {
+      "amount": "0.01",
+      "amount-usd": "0.01",
+      "button-data": "{\"order\":\"123\"}",
+      "button-id": "order-123",
+      "created-at": "2019-03-26T17:33:42.788Z",
+      "currency": "USD",
+      "normalized-txid": "a2f4c7a7a1c3...",
+      "payment-outputs": [
+        {
+          "id": "1",
+          "to": "mrz@moneybutton.com",
+          "type": "PAYMAIL",
+          "amount": "0.01",
+          "currency": "USD",
+          "satoshis": "4210",
+          "user-id": "123"
+        }
+      ],
+      "satoshis": "4210",
+      "status": "COMPLETED",
+      "status-description": null,
+      "txid": "d4e8f9c0b1a2...",
+      "updated-at": "2019-03-26T17:33:45.123Z",
+      "user-id": "456"
+    }
+  }
+}
+*/
+
+// GetPayment returns a single payment by ID
+//
+// Specs: https://docs.moneybutton.com/docs/api-rest-payments.html
+func (c *Client) GetPayment(ctx context.Context, paymentID, accessToken string) (*Payment, error) {
+
+	// Check required parameters
+	if len(accessToken) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "accessToken")
+	} else if len(paymentID) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "paymentID")
+	}
+
+	// Fire the request
+	response := httpRequest(
+		ctx,
+		c,
+		&httpPayload{
+			Endpoint:       "GetPayment",
+			ExpectedStatus: http.StatusOK,
+			Method:         http.MethodGet,
+			Token:          accessToken,
+			URL:            c.apiURL(fmt.Sprintf(endpointPayment, url.PathEscape(paymentID))),
+		},
+	)
+
+	// Error in request?
+	if response.Error != nil {
+		return nil, response.Error
+	}
+
+	// Create the response
+	doc, err := DecodeDocument(response.BodyContents)
+	if err != nil {
+		return nil, err
+	}
+	var resource *Resource
+	if resource, err = doc.Resource(); err != nil {
+		return nil, err
+	}
+	var data *PaymentData
+	if data, err = paymentFromResource(doc, resource); err != nil {
+		return nil, err
+	}
+	return &Payment{Data: data, JSONAPI: doc.JSONAPI, Links: doc.Links, Meta: doc.Meta}, nil
+}
+
+// paymentFromResource will decode the payment and resolve the payment outputs
+// from the included resources (if they are not in the attributes)
+func paymentFromResource(doc *Document, resource *Resource) (*PaymentData, error) {
+	payment, err := DecodeResource[PaymentAttributes](resource)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve the outputs
+	var related []*Resource
+	if related, err = doc.Related(resource, relationshipPaymentOutputs); err != nil {
+		return nil, err
+	} else if len(related) == 0 {
+		return payment, nil
+	}
+	if payment.Attributes == nil {
+		payment.Attributes = new(PaymentAttributes)
+	}
+	if len(payment.Attributes.PaymentOutputs) > 0 {
+		return payment, nil
+	}
+	for _, outputResource := range related {
+		output := new(PaymentOutput)
+		if err = outputResource.Decode(output); err != nil {
+			return nil, err
+		}
+		if len(output.ID) == 0 {
+			output.ID = outputResource.ID
+		}
+		payment.Attributes.PaymentOutputs = append(payment.Attributes.PaymentOutputs, output)
+	}
+	return payment, nil
+}
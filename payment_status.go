@@ -0,0 +1,185 @@
+package moneybutton
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// PaymentStatus is the status of a payment (IE: COMPLETED)
+type PaymentStatus string
+
+// Payment statuses
+//
+// Specs: https://docs.moneybutton.com/docs/api-rest-payments.html
+const (
+	PaymentStatusCompleted PaymentStatus = "COMPLETED" // Broadcast and accepted by the network
+	PaymentStatusFailed    PaymentStatus = "FAILED"    // Rejected (final)
+	PaymentStatusPending   PaymentStatus = "PENDING"   // Created but not yet received
+	PaymentStatusReceived  PaymentStatus = "RECEIVED"  // Received but not yet broadcast
+	PaymentStatusRefunded  PaymentStatus = "REFUNDED"  // Completed and then refunded (final)
+)
+
+// Payment status errors (use errors.Is() on a TransitionError)
+var (
+	// ErrInvalidTransition is returned when the payment cannot move from the current status to the next status
+	ErrInvalidTransition = errors.New("invalid payment status transition")
+
+	// ErrOutOfOrderTransition is returned when the next status is before the current status (a late delivery)
+	ErrOutOfOrderTransition = errors.New("out of order payment status transition")
+
+	// ErrUnknownPaymentStatus is returned when the status is not a known payment status
+	ErrUnknownPaymentStatus = errors.New("unknown payment status")
+)
+
+// paymentTransitions are the statuses each status can move to
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusCompleted: {PaymentStatusRefunded},
+	PaymentStatusFailed:    {},
+	PaymentStatusPending:   {PaymentStatusCompleted, PaymentStatusFailed, PaymentStatusReceived},
+	PaymentStatusReceived:  {PaymentStatusCompleted, PaymentStatusFailed},
+	PaymentStatusRefunded:  {},
+}
+
+// Valid will return true if the status is a known payment status
+func (s PaymentStatus) Valid() bool {
+	_, ok := paymentTransitions[s]
+	return ok
+}
+
+// Terminal will return true if the status is final (FAILED or REFUNDED)
+func (s PaymentStatus) Terminal() bool {
+	next, ok := paymentTransitions[s]
+	return ok && len(next) == 0
+}
+
+// CanTransitionTo will return true if the payment can move directly to the next status
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, status := range paymentTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
+// String will return the status
+func (s PaymentStatus) String() string {
+	return string(s)
+}
+
+// reachable will return true if the next status can be reached from the status (in any number of transitions)
+func (s PaymentStatus) reachable(next PaymentStatus) bool {
+	for _, status := range paymentTransitions[s] {
+		if status == next || status.reachable(next) {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionError is returned when a payment status update is not a valid transition
+//
+// errors.Is() matches ErrInvalidTransition, and ErrOutOfOrderTransition when the
+// update is for an earlier status (IE: RECEIVED after COMPLETED)
+type TransitionError struct {
+	From      PaymentStatus `json:"from"`       // Current status
+	PaymentID string        `json:"payment_id"` // Payment ID
+	To        PaymentStatus `json:"to"`         // Rejected status
+}
+
+// Error will return the error message
+func (e *TransitionError) Error() string {
+	message := fmt.Sprintf("payment %s: invalid status transition from %s to %s", e.PaymentID, e.From, e.To)
+	if e.OutOfOrder() {
+		message += " (out of order)"
+	}
+	return message
+}
+
+// OutOfOrder will return true if the rejected status is before the current status
+func (e *TransitionError) OutOfOrder() bool {
+	return e.To.reachable(e.From)
+}
+
+// Is will match ErrInvalidTransition and (if out of order) ErrOutOfOrderTransition
+func (e *TransitionError) Is(target error) bool {
+	switch target {
+	case ErrInvalidTransition:
+		return true
+	case ErrOutOfOrderTransition:
+		return e.OutOfOrder()
+	}
+	return false
+}
+
+// PaymentStateMachine tracks the status of payments and validates every status update
+//
+// Updates can come from any source (GetPayment, ListPayments, webhooks or polling),
+// repeated updates with the same status are ignored
+type PaymentStateMachine struct {
+	mu       sync.RWMutex
+	statuses map[string]PaymentStatus
+}
+
+// NewPaymentStateMachine will create a new (empty) PaymentStateMachine
+func NewPaymentStateMachine() *PaymentStateMachine {
+	return &PaymentStateMachine{statuses: make(map[string]PaymentStatus)}
+}
+
+// Status will return the current status of the payment (false if the payment is not tracked)
+func (m *PaymentStateMachine) Status(paymentID string) (PaymentStatus, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	status, ok := m.statuses[paymentID]
+	return status, ok
+}
+
+// Update will move the payment to the status and return true if the status changed
+//
+// The first update of a payment accepts any known status. Statuses can be skipped
+// (IE: polling can see PENDING and then REFUNDED), so any status reachable from the
+// current status is accepted. An earlier or impossible status returns a *TransitionError
+// and the current status is kept.
+func (m *PaymentStateMachine) Update(paymentID string, status PaymentStatus) (bool, error) {
+	if len(paymentID) == 0 {
+		return false, fmt.Errorf("missing required parameter: %s", "paymentID")
+	} else if !status.Valid() {
+		return false, fmt.Errorf("%w: %q", ErrUnknownPaymentStatus, status)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.statuses[paymentID]
+	if ok && current == status {
+		return false, nil
+	} else if ok && !current.reachable(status) {
+		return false, &TransitionError{From: current, PaymentID: paymentID, To: status}
+	}
+	m.statuses[paymentID] = status
+	return true, nil
+}
+
+// UpdatePayment will update the status from a payment resource (GetPayment or ListPayments)
+func (m *PaymentStateMachine) UpdatePayment(payment *PaymentData) (bool, error) {
+	if payment == nil || payment.Attributes == nil {
+		return false, fmt.Errorf("missing required parameter: %s", "payment")
+	}
+	return m.Update(payment.ID, payment.Attributes.Status)
+}
+
+// UpdateWebhook will update the status from a webhook payment
+func (m *PaymentStateMachine) UpdateWebhook(payment *WebhookPayment) (bool, error) {
+	if payment == nil {
+		return false, fmt.Errorf("missing required parameter: %s", "payment")
+	}
+	return m.Update(payment.ID, payment.Status)
+}
+
+// Forget will stop tracking the payment
+func (m *PaymentStateMachine) Forget(paymentID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.statuses, paymentID)
+}
@@ -0,0 +1,299 @@
+package moneybutton
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPaymentStatus tests the methods Valid(), Terminal() and CanTransitionTo()
+func TestPaymentStatus(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		for _, status := range []PaymentStatus{
+			PaymentStatusCompleted, PaymentStatusFailed, PaymentStatusPending,
+			PaymentStatusReceived, PaymentStatusRefunded,
+		} {
+			assert.True(t, status.Valid(), status)
+		}
+		assert.False(t, PaymentStatus("").Valid())
+		assert.False(t, PaymentStatus("completed").Valid())
+	})
+
+	t.Run("terminal", func(t *testing.T) {
+		assert.True(t, PaymentStatusFailed.Terminal())
+		assert.True(t, PaymentStatusRefunded.Terminal())
+		assert.False(t, PaymentStatusCompleted.Terminal())
+		assert.False(t, PaymentStatusPending.Terminal())
+		assert.False(t, PaymentStatus("UNKNOWN").Terminal())
+	})
+
+	t.Run("transitions", func(t *testing.T) {
+		tests := []struct {
+			from, to PaymentStatus
+			expected bool
+		}{
+			{PaymentStatusPending, PaymentStatusReceived, true},
+			{PaymentStatusPending, PaymentStatusCompleted, true},
+			{PaymentStatusPending, PaymentStatusFailed, true},
+			{PaymentStatusReceived, PaymentStatusCompleted, true},
+			{PaymentStatusReceived, PaymentStatusFailed, true},
+			{PaymentStatusCompleted, PaymentStatusRefunded, true},
+			{PaymentStatusPending, PaymentStatusRefunded, false},
+			{PaymentStatusCompleted, PaymentStatusReceived, false},
+			{PaymentStatusCompleted, PaymentStatusFailed, false},
+			{PaymentStatusFailed, PaymentStatusCompleted, false},
+			{PaymentStatusRefunded, PaymentStatusCompleted, false},
+			{PaymentStatusCompleted, PaymentStatusCompleted, false},
+		}
+		for _, test := range tests {
+			assert.Equal(t, test.expected, test.from.CanTransitionTo(test.to), "%s to %s", test.from, test.to)
+		}
+	})
+
+	t.Run("string", func(t *testing.T) {
+		assert.Equal(t, "COMPLETED", PaymentStatusCompleted.String())
+	})
+}
+
+// TestTransitionError tests the TransitionError
+func TestTransitionError(t *testing.T) {
+	t.Parallel()
+
+	t.Run("out of order", func(t *testing.T) {
+		err := &TransitionError{From: PaymentStatusCompleted, PaymentID: "1040", To: PaymentStatusReceived}
+		assert.True(t, err.OutOfOrder())
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+		assert.ErrorIs(t, err, ErrOutOfOrderTransition)
+		assert.Equal(t, "payment 1040: invalid status transition from COMPLETED to RECEIVED (out of order)", err.Error())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := &TransitionError{From: PaymentStatusFailed, PaymentID: "1040", To: PaymentStatusCompleted}
+		assert.False(t, err.OutOfOrder())
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+		assert.False(t, errors.Is(err, ErrOutOfOrderTransition))
+		assert.False(t, errors.Is(err, ErrUnknownPaymentStatus))
+		assert.Equal(t, "payment 1040: invalid status transition from FAILED to COMPLETED", err.Error())
+	})
+
+	t.Run("wrapped", func(t *testing.T) {
+		err := fmt.Errorf("webhook: %w", &TransitionError{From: PaymentStatusCompleted, PaymentID: "1040", To: PaymentStatusPending})
+		var transitionErr *TransitionError
+		require.True(t, errors.As(err, &transitionErr))
+		assert.Equal(t, "1040", transitionErr.PaymentID)
+		assert.ErrorIs(t, err, ErrOutOfOrderTransition)
+	})
+}
+
+// TestPaymentStateMachine_Update tests the method Update()
+func TestPaymentStateMachine_Update(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing payment id", func(t *testing.T) {
+		machine := NewPaymentStateMachine()
+		changed, err := machine.Update("", PaymentStatusPending)
+		assert.Error(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		machine := NewPaymentStateMachine()
+		changed, err := machine.Update("1040", "UNKNOWN")
+		assert.ErrorIs(t, err, ErrUnknownPaymentStatus)
+		assert.False(t, changed)
+		_, ok := machine.Status("1040")
+		assert.False(t, ok)
+	})
+
+	t.Run("valid transitions", func(t *testing.T) {
+		machine := NewPaymentStateMachine()
+		for _, status := range []PaymentStatus{
+			PaymentStatusPending, PaymentStatusReceived, PaymentStatusCompleted, PaymentStatusRefunded,
+		} {
+			changed, err := machine.Update("1040", status)
+			require.NoError(t, err)
+			assert.True(t, changed)
+			current, ok := machine.Status("1040")
+			require.True(t, ok)
+			assert.Equal(t, status, current)
+		}
+	})
+
+	t.Run("skipped statuses are accepted", func(t *testing.T) {
+		tests := []struct {
+			from PaymentStatus
+			to   PaymentStatus
+		}{
+			{PaymentStatusPending, PaymentStatusRefunded},
+			{PaymentStatusReceived, PaymentStatusRefunded},
+		}
+		for _, test := range tests {
+			machine := NewPaymentStateMachine()
+			_, err := machine.Update("1040", test.from)
+			require.NoError(t, err)
+
+			changed, err := machine.Update("1040", test.to)
+			require.NoError(t, err, "%s to %s", test.from, test.to)
+			assert.True(t, changed)
+			current, _ := machine.Status("1040")
+			assert.Equal(t, test.to, current)
+		}
+	})
+
+	t.Run("first update accepts any status", func(t *testing.T) {
+		machine := NewPaymentStateMachine()
+		changed, err := machine.Update("1040", PaymentStatusCompleted)
+		require.NoError(t, err)
+		assert.True(t, changed)
+	})
+
+	t.Run("repeated status is ignored", func(t *testing.T) {
+		machine := NewPaymentStateMachine()
+		_, err := machine.Update("1040", PaymentStatusCompleted)
+		require.NoError(t, err)
+		changed, err := machine.Update("1040", PaymentStatusCompleted)
+		require.NoError(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("out of order delivery", func(t *testing.T) {
+		machine := NewPaymentStateMachine()
+		_, err := machine.Update("1040", PaymentStatusCompleted)
+		require.NoError(t, err)
+
+		changed, err := machine.Update("1040", PaymentStatusReceived)
+		assert.False(t, changed)
+		assert.ErrorIs(t, err, ErrOutOfOrderTransition)
+
+		var transitionErr *TransitionError
+		require.True(t, errors.As(err, &transitionErr))
+		assert.Equal(t, PaymentStatusCompleted, transitionErr.From)
+		assert.Equal(t, PaymentStatusReceived, transitionErr.To)
+
+		// The current status is kept
+		current, _ := machine.Status("1040")
+		assert.Equal(t, PaymentStatusCompleted, current)
+	})
+
+	t.Run("terminal status", func(t *testing.T) {
+		machine := NewPaymentStateMachine()
+		_, err := machine.Update("1040", PaymentStatusFailed)
+		require.NoError(t, err)
+
+		_, err = machine.Update("1040", PaymentStatusCompleted)
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+		assert.False(t, errors.Is(err, ErrOutOfOrderTransition))
+	})
+
+	t.Run("impossible transition", func(t *testing.T) {
+		machine := NewPaymentStateMachine()
+		_, err := machine.Update("1040", PaymentStatusFailed)
+		require.NoError(t, err)
+
+		changed, err := machine.Update("1040", PaymentStatusRefunded)
+		assert.False(t, changed)
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+		assert.False(t, errors.Is(err, ErrOutOfOrderTransition))
+	})
+
+	t.Run("forget", func(t *testing.T) {
+		machine := NewPaymentStateMachine()
+		_, err := machine.Update("1040", PaymentStatusFailed)
+		require.NoError(t, err)
+		machine.Forget("1040")
+		_, ok := machine.Status("1040")
+		assert.False(t, ok)
+	})
+
+	t.Run("concurrent updates", func(t *testing.T) {
+		machine := NewPaymentStateMachine()
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, _ = machine.Update(fmt.Sprintf("%d", i%5), PaymentStatusCompleted)
+			}(i)
+		}
+		wg.Wait()
+		for i := 0; i < 5; i++ {
+			status, ok := machine.Status(fmt.Sprintf("%d", i))
+			assert.True(t, ok)
+			assert.Equal(t, PaymentStatusCompleted, status)
+		}
+	})
+}
+
+// TestPaymentStateMachine_Sources tests the methods UpdatePayment() and UpdateWebhook()
+func TestPaymentStateMachine_Sources(t *testing.T) {
+	t.Parallel()
+
+	machine := NewPaymentStateMachine()
+
+	_, err := machine.UpdatePayment(nil)
+	assert.Error(t, err)
+	_, err = machine.UpdatePayment(&PaymentData{ID: "1040"})
+	assert.Error(t, err)
+	_, err = machine.UpdateWebhook(nil)
+	assert.Error(t, err)
+
+	// From the API
+	changed, err := machine.UpdatePayment(&PaymentData{
+		ID: "1040", Attributes: &PaymentAttributes{Status: PaymentStatusReceived},
+	})
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	// From a webhook
+	changed, err = machine.UpdateWebhook(&WebhookPayment{ID: "1040", Status: PaymentStatusCompleted})
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	// A late webhook
+	changed, err = machine.UpdateWebhook(&WebhookPayment{ID: "1040", Status: PaymentStatusReceived})
+	assert.ErrorIs(t, err, ErrOutOfOrderTransition)
+	assert.False(t, changed)
+}
+
+// TestPaymentStateMachine_Polling tests polling the payments (statuses between polls are missed)
+func TestPaymentStateMachine_Polling(t *testing.T) {
+	t.Parallel()
+
+	machine := NewPaymentStateMachine()
+	polls := [][]PaymentStatus{
+		{PaymentStatusPending, PaymentStatusPending},
+		{PaymentStatusCompleted, PaymentStatusPending}, // RECEIVED was missed for 1040
+		{PaymentStatusRefunded, PaymentStatusFailed},   // RECEIVED was missed for 1041
+		{PaymentStatusRefunded, PaymentStatusFailed},
+	}
+	var changes int
+	for _, poll := range polls {
+		for i, status := range poll {
+			changed, err := machine.UpdatePayment(&PaymentData{
+				ID: fmt.Sprintf("%d", 1040+i), Attributes: &PaymentAttributes{Status: status},
+			})
+			require.NoError(t, err)
+			if changed {
+				changes++
+			}
+		}
+	}
+	assert.Equal(t, 5, changes)
+
+	status, _ := machine.Status("1040")
+	assert.Equal(t, PaymentStatusRefunded, status)
+	status, _ = machine.Status("1041")
+	assert.Equal(t, PaymentStatusFailed, status)
+
+	// A stale poll (IE: from a cache) is still rejected
+	_, err := machine.UpdatePayment(&PaymentData{
+		ID: "1040", Attributes: &PaymentAttributes{Status: PaymentStatusCompleted},
+	})
+	assert.ErrorIs(t, err, ErrOutOfOrderTransition)
+}
@@ -0,0 +1,139 @@
+package moneybutton
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPaymentJSON is a single payment resource
+const testPaymentJSON = `{"type":"payments","id":"1040","attributes":{"amount":"0.01","amount-usd":"0.01","button-data":"{\"order\":\"123\"}","button-id":"order-123","created-at":"2019-03-26T17:33:42.788Z","currency":"USD","normalized-txid":"n1234","payment-outputs":[{"id":"1","to":"mrz@moneybutton.com","type":"PAYMAIL","amount":"0.01","currency":"USD","satoshis":"4210","user-id":"123"}],"satoshis":"4210","status":"COMPLETED","status-description":null,"txid":"t1234","updated-at":"2019-03-26T17:33:45.123Z","user-id":"456"}}`
+
+// mockHTTPGetPayment for mocking requests
+type mockHTTPGetPayment struct{}
+
+// Do is a mock http request
+func (m *mockHTTPGetPayment) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+	resp.StatusCode = http.StatusBadRequest
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	if req.URL.String() == APIURL+fmt.Sprintf(endpointPayment, "1040") {
+		resp.StatusCode = http.StatusOK
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"data":` + testPaymentJSON + `,"jsonapi":{"version":"1.0"}}`)))
+	} else if req.URL.String() == APIURL+fmt.Sprintf(endpointPayment, "1041") {
+		resp.StatusCode = http.StatusOK
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"data":{"type":"payments","id":"1041","attributes":{"status":"COMPLETED"},"relationships":{"payment-outputs":{"data":[{"type":"payment-outputs","id":"7"},{"type":"payment-outputs","id":"8"}]}}},"included":[{"type":"payment-outputs","id":"7","attributes":{"to":"mrz@moneybutton.com","type":"PAYMAIL","amount":"0.01"}},{"type":"users","id":"123","attributes":{"name":"MrZ"}}]}`)))
+	} else {
+		resp.StatusCode = http.StatusNotFound
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"errors":[{"id":"abc","status":404,"title":"Not Found","detail":"Payment not found"}],"jsonapi":{"version":"1.0"}}`)))
+	}
+
+	// Default is valid
+	return resp, nil
+}
+
+func TestClient_GetPayment(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing all parameters", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetPayment{})
+		payment, err := client.GetPayment(context.Background(), "", "")
+		assert.Error(t, err)
+		assert.Nil(t, payment)
+	})
+
+	t.Run("missing payment id", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetPayment{})
+		payment, err := client.GetPayment(context.Background(), "", "1234567")
+		assert.Error(t, err)
+		assert.Nil(t, payment)
+	})
+
+	t.Run("missing access token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetPayment{})
+		payment, err := client.GetPayment(context.Background(), "1040", "")
+		assert.Error(t, err)
+		assert.Nil(t, payment)
+	})
+
+	t.Run("payment not found", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetPayment{})
+		payment, err := client.GetPayment(context.Background(), "999", "1234567")
+		require.Error(t, err)
+		assert.Nil(t, payment)
+
+		var apiErr *APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		assert.Equal(t, []string{"abc"}, apiErr.ErrorIDs())
+	})
+
+	t.Run("http error", func(t *testing.T) {
+		client := newTestClient(&mockHTTPError{})
+		payment, err := client.GetPayment(context.Background(), "1040", "1234567")
+		assert.Error(t, err)
+		assert.Nil(t, payment)
+	})
+
+	t.Run("valid response", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetPayment{})
+		payment, err := client.GetPayment(context.Background(), "1040", "1234567")
+		require.NoError(t, err)
+		require.NotNil(t, payment)
+		assert.Equal(t, "payments", payment.Data.Type)
+		assert.Equal(t, "1040", payment.Data.ID)
+
+		attributes := payment.Data.Attributes
+		require.NotNil(t, attributes)
+		assert.Equal(t, "0.01", attributes.Amount.String())
+		assert.Equal(t, "0.01", attributes.AmountUSD.String())
+		assert.Equal(t, `{"order":"123"}`, attributes.ButtonData)
+		assert.Equal(t, "order-123", attributes.ButtonID)
+		assert.Equal(t, time.Date(2019, 3, 26, 17, 33, 42, 788000000, time.UTC), attributes.CreatedAt)
+		assert.Equal(t, "USD", attributes.Currency)
+		assert.Equal(t, "n1234", attributes.NormalizedTxID)
+		assert.Equal(t, "4210", attributes.Satoshis.String())
+		assert.Equal(t, PaymentStatusCompleted, attributes.Status)
+		assert.Equal(t, "", attributes.StatusDescription)
+		assert.Equal(t, "t1234", attributes.TxID)
+		assert.Equal(t, time.Date(2019, 3, 26, 17, 33, 45, 123000000, time.UTC), attributes.UpdatedAt)
+		assert.Equal(t, "456", attributes.UserID)
+
+		require.Len(t, attributes.PaymentOutputs, 1)
+		output := attributes.PaymentOutputs[0]
+		assert.Equal(t, "1", output.ID)
+		assert.Equal(t, "mrz@moneybutton.com", output.To)
+		assert.Equal(t, "PAYMAIL", output.Type)
+		assert.Equal(t, "0.01", output.Amount.String())
+		assert.Equal(t, "USD", output.Currency)
+		assert.Equal(t, "4210", output.Satoshis.String())
+		assert.Equal(t, "123", output.UserID)
+	})
+
+	t.Run("included payment outputs", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetPayment{})
+		payment, err := client.GetPayment(context.Background(), "1041", "1234567")
+		require.NoError(t, err)
+		require.NotNil(t, payment.Data.Attributes)
+
+		// Identifiers that are not included are skipped
+		outputs := payment.Data.Attributes.PaymentOutputs
+		require.Len(t, outputs, 1)
+		assert.Equal(t, "7", outputs[0].ID)
+		assert.Equal(t, "mrz@moneybutton.com", outputs[0].To)
+		assert.Equal(t, "0.01", outputs[0].Amount.String())
+	})
+}
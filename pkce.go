@@ -0,0 +1,67 @@
+package moneybutton
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// PKCE is the proof key for code exchange (RFC 7636) used by public clients
+// that cannot hold a client secret (mobile apps, single page apps, etc.)
+// Send the CodeChallenge on the authorization request and the CodeVerifier on the token exchange
+//
+// Specs: https://datatracker.ietf.org/doc/html/rfc7636
+type PKCE struct {
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"` // "S256"
+	CodeVerifier        string `json:"code_verifier"`
+}
+
+// NewPKCE will generate a new random code verifier and its S256 code challenge
+func NewPKCE() (*PKCE, error) {
+	b := make([]byte, pkceVerifierBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return NewPKCEFromVerifier(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// NewPKCEFromVerifier will create the S256 code challenge for an existing code verifier
+func NewPKCEFromVerifier(codeVerifier string) (*PKCE, error) {
+	if err := validateCodeVerifier(codeVerifier); err != nil {
+		return nil, err
+	}
+	return &PKCE{
+		CodeChallenge:       codeChallengeS256(codeVerifier),
+		CodeChallengeMethod: pkceChallengeMethodS256,
+		CodeVerifier:        codeVerifier,
+	}, nil
+}
+
+// codeChallengeS256 will return BASE64URL(SHA256(verifier)) without padding
+func codeChallengeS256(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// validateCodeVerifier will check the length and character set of a code verifier
+func validateCodeVerifier(codeVerifier string) error {
+	if len(codeVerifier) < pkceVerifierMinLength || len(codeVerifier) > pkceVerifierMaxLength {
+		return fmt.Errorf(
+			"invalid code verifier length: %d (must be %d-%d characters)",
+			len(codeVerifier), pkceVerifierMinLength, pkceVerifierMaxLength,
+		)
+	}
+
+	// Only unreserved characters are allowed: [A-Z] / [a-z] / [0-9] / "-" / "." / "_" / "~"
+	for _, r := range codeVerifier {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9',
+			r == '-', r == '.', r == '_', r == '~':
+		default:
+			return fmt.Errorf("invalid character in code verifier: %q", r)
+		}
+	}
+	return nil
+}
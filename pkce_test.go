@@ -0,0 +1,85 @@
+package moneybutton
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcCodeVerifier and rfcCodeChallenge are the example values from RFC 7636 (Appendix B)
+const (
+	rfcCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	rfcCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+// TestNewPKCE tests the method NewPKCE()
+func TestNewPKCE(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid pkce", func(t *testing.T) {
+		pkce, err := NewPKCE()
+		require.NoError(t, err)
+		require.NotNil(t, pkce)
+		assert.Len(t, pkce.CodeVerifier, pkceVerifierMinLength)
+		assert.Equal(t, pkceChallengeMethodS256, pkce.CodeChallengeMethod)
+		assert.Equal(t, codeChallengeS256(pkce.CodeVerifier), pkce.CodeChallenge)
+		assert.NoError(t, validateCodeVerifier(pkce.CodeVerifier))
+	})
+
+	t.Run("unique verifiers", func(t *testing.T) {
+		pkce1, err := NewPKCE()
+		require.NoError(t, err)
+		pkce2, err := NewPKCE()
+		require.NoError(t, err)
+		assert.NotEqual(t, pkce1.CodeVerifier, pkce2.CodeVerifier)
+	})
+}
+
+// TestNewPKCEFromVerifier tests the method NewPKCEFromVerifier()
+func TestNewPKCEFromVerifier(t *testing.T) {
+	t.Parallel()
+
+	t.Run("rfc example", func(t *testing.T) {
+		pkce, err := NewPKCEFromVerifier(rfcCodeVerifier)
+		require.NoError(t, err)
+		assert.Equal(t, rfcCodeChallenge, pkce.CodeChallenge)
+		assert.Equal(t, pkceChallengeMethodS256, pkce.CodeChallengeMethod)
+		assert.Equal(t, rfcCodeVerifier, pkce.CodeVerifier)
+	})
+
+	t.Run("too short", func(t *testing.T) {
+		pkce, err := NewPKCEFromVerifier("short")
+		assert.Error(t, err)
+		assert.Nil(t, pkce)
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		pkce, err := NewPKCEFromVerifier(strings.Repeat("a", pkceVerifierMaxLength+1))
+		assert.Error(t, err)
+		assert.Nil(t, pkce)
+	})
+
+	t.Run("invalid characters", func(t *testing.T) {
+		pkce, err := NewPKCEFromVerifier(strings.Repeat("a", pkceVerifierMinLength) + "+/=")
+		assert.Error(t, err)
+		assert.Nil(t, pkce)
+	})
+}
+
+// ExampleNewPKCEFromVerifier example using NewPKCEFromVerifier()
+func ExampleNewPKCEFromVerifier() {
+	pkce, _ := NewPKCEFromVerifier(rfcCodeVerifier)
+
+	fmt.Printf("code challenge: %s", pkce.CodeChallenge)
+	// Output:code challenge: E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM
+}
+
+// BenchmarkNewPKCE benchmarks the method NewPKCE()
+func BenchmarkNewPKCE(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = NewPKCE()
+	}
+}
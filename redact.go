@@ -0,0 +1,255 @@
+package moneybutton
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted is the value that replaces tokens, codes and secrets in debug output and error messages
+const Redacted = "[REDACTED]"
+
+// minSecretLength is the minimum length of a secret to be searched for in free text (IE: error messages)
+//
+// Shorter values would mask common words (IE: "code") in the messages returned by the API
+const minSecretLength = 8
+
+// secretFields are the form, query and JSON fields that contain secrets
+var secretFields = []string{
+	"access_token", "client_secret", "code_verifier", "password", "refresh_token", "secret",
+}
+
+// secretFormFields are the form and query fields that only contain secrets in oAuth requests
+//
+// The code is the authorization code of the redirect and the token request, in a JSON body
+// it is the code of an error object (needed to diagnose the error)
+var secretFormFields = []string{"code"}
+
+// redactHeaders are the request and response headers that contain secrets
+var redactHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization", "Set-Cookie"}
+
+// defaultRedactor masks the secret fields with Redacted (used by the Redact* functions)
+var defaultRedactor = NewRedactor(Redacted)
+
+// Redactor masks the tokens, codes and secrets (and any extra fields) in form, query and JSON data
+//
+// A Redactor is not changed after it is created (safe for concurrent use)
+type Redactor struct {
+	fields      map[string]bool // Masked everywhere
+	formFields  map[string]bool // Only masked in forms and query strings
+	replacement string
+}
+
+// NewRedactor will create a Redactor that replaces the secret fields and the extra fields with the replacement
+func NewRedactor(replacement string, fields ...string) *Redactor {
+	r := &Redactor{
+		fields:      make(map[string]bool, len(secretFields)+len(fields)),
+		formFields:  make(map[string]bool, len(secretFormFields)),
+		replacement: replacement,
+	}
+	for _, field := range append(append([]string{}, secretFields...), fields...) {
+		r.fields[field] = true
+	}
+	for _, field := range secretFormFields {
+		r.formFields[field] = true
+	}
+	return r
+}
+
+// RedactForm will mask the secrets in form encoded data (IE: the code and refresh_token of a token request)
+//
+// Data that cannot be parsed is fully redacted
+func RedactForm(data string) string {
+	return defaultRedactor.Form(data)
+}
+
+// RedactURL will mask the secrets in the query string of the URL
+func RedactURL(rawURL string) string {
+	return defaultRedactor.URL(rawURL)
+}
+
+// RedactJSON will mask the secrets in a JSON body (IE: the access_token of a token response)
+//
+// A body that is not JSON is returned as-is
+func RedactJSON(body []byte) []byte {
+	redacted, _ := defaultRedactor.JSON(body)
+	return redacted
+}
+
+// RedactHeader will return a copy of the header with the credentials masked
+//
+// The scheme of the Authorization header is kept (IE: "Bearer [REDACTED]")
+func RedactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, key := range redactHeaders {
+		values := redacted.Values(key)
+		for i, value := range values {
+			if scheme, _, found := strings.Cut(value, " "); found && strings.HasSuffix(key, "Authorization") {
+				values[i] = scheme + " " + Redacted
+			} else {
+				values[i] = Redacted
+			}
+		}
+	}
+	return redacted
+}
+
+// Form will mask the fields in form encoded data (data that cannot be parsed is fully masked)
+func (r *Redactor) Form(data string) string {
+	if len(data) == 0 {
+		return data
+	}
+	form, err := url.ParseQuery(data)
+	if err != nil {
+		return r.replacement
+	}
+	return r.values(form).Encode()
+}
+
+// URL will mask the fields in the query string of the URL (the query is sorted by key)
+func (r *Redactor) URL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || len(u.RawQuery) == 0 {
+		return rawURL
+	}
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		u.RawQuery = r.replacement
+	} else {
+		u.RawQuery = r.values(query).Encode()
+	}
+	return u.String()
+}
+
+// JSON will mask the fields in a JSON body (false if the body is not a JSON object or array)
+func (r *Redactor) JSON(body []byte) ([]byte, bool) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return body, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return body, false
+	}
+
+	redacted, err := json.Marshal(r.value(value))
+	if err != nil {
+		return body, false
+	}
+	return redacted, true
+}
+
+// values will mask the form or query values of the fields
+func (r *Redactor) values(values url.Values) url.Values {
+	for key, value := range values {
+		if r.formField(key) {
+			for i := range value {
+				value[i] = r.replacement
+			}
+		}
+	}
+	return values
+}
+
+// formField will return true if the form or query field is masked
+func (r *Redactor) formField(key string) bool {
+	return r.fields[key] || r.formFields[key]
+}
+
+// value will mask the string values of the fields (recursively)
+func (r *Redactor) value(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if _, isString := field.(string); isString && r.fields[key] {
+				v[key] = r.replacement
+			} else {
+				v[key] = r.value(field)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = r.value(v[i])
+		}
+	}
+	return value
+}
+
+// redactSecrets will mask every occurrence of the secrets in the text
+func redactSecrets(text string, secrets []string) string {
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			text = strings.ReplaceAll(text, secret, Redacted)
+		}
+	}
+	return text
+}
+
+// payloadSecrets will return the secrets sent with the request (the token and the secret form values)
+func payloadSecrets(payload *httpPayload) (secrets []string) {
+	if len(payload.Token) > 0 {
+		secrets = append(secrets, payload.Token)
+	}
+	if len(payload.Data) > 0 {
+		if form, err := url.ParseQuery(payload.Data); err == nil {
+			for key, values := range form {
+				if defaultRedactor.formField(key) {
+					secrets = append(secrets, values...)
+				}
+			}
+		}
+	}
+	return
+}
+
+// redactError will mask the secrets in the error message (the error type is kept for errors.Is/As)
+func redactError(err error, secrets []string) error {
+	switch e := err.(type) {
+	case *APIError:
+		e.URL = RedactURL(e.URL)
+		for _, errObj := range e.Errors {
+			errObj.Detail = redactSecrets(errObj.Detail, secrets)
+			errObj.Title = redactSecrets(errObj.Title, secrets)
+		}
+	case *url.Error:
+		e.URL = RedactURL(e.URL)
+	default:
+		if msg := redactSecrets(err.Error(), secrets); msg != err.Error() {
+			return &redactedError{err: err, msg: msg}
+		}
+	}
+	return err
+}
+
+// redactedError is an error with the secrets masked in the message (IE: the retry errors of heimdall)
+type redactedError struct {
+	err error
+	msg string
+}
+
+// Error will return the redacted message
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+// Unwrap will return the original error (for errors.Is/As)
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// Redact will return a copy of the response with the secrets masked (safe for logging)
+//
+// Any token or code that was sent with the request is also masked if it is echoed in the body,
+// the post data and error are already redacted by the Client (unless ClientOptions.DisableRedaction is set)
+func (r *RequestResponse) Redact() *RequestResponse {
+	redacted := *r
+	redacted.BodyContents = []byte(redactSecrets(string(RedactJSON(r.BodyContents)), r.secrets))
+	redacted.PostData = RedactForm(r.PostData)
+	redacted.URL = RedactURL(r.URL)
+	return &redacted
+}
@@ -0,0 +1,278 @@
+package moneybutton
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSecretAccessToken  = "access-token-1234567890"
+	testSecretRefreshToken = "refresh-token-1234567890"
+)
+
+// TestRedactForm tests the method RedactForm()
+func TestRedactForm(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"no secrets", "grant_type=refresh_token&client_id=123", "client_id=123&grant_type=refresh_token"},
+		{"refresh token", "grant_type=refresh_token&refresh_token=abc", "grant_type=refresh_token&refresh_token=%5BREDACTED%5D"},
+		{"code and verifier", "code=abc&code_verifier=xyz", "code=%5BREDACTED%5D&code_verifier=%5BREDACTED%5D"},
+		{"client secret", "client_secret=abc", "client_secret=%5BREDACTED%5D"},
+		{"invalid", "refresh_token=%zz", Redacted},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, RedactForm(test.data))
+		})
+	}
+}
+
+// TestRedactURL tests the method RedactURL()
+func TestRedactURL(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "https://example.com/api/v1/payments", RedactURL("https://example.com/api/v1/payments"))
+	assert.Equal(t, "https://example.com/callback?code=%5BREDACTED%5D&state=xyz",
+		RedactURL("https://example.com/callback?code=abc&state=xyz"))
+	assert.Equal(t, "https://example.com/?"+Redacted, RedactURL("https://example.com/?access_token=%zz"))
+	assert.Equal(t, "://invalid", RedactURL("://invalid"))
+}
+
+// TestRedactJSON tests the method RedactJSON()
+func TestRedactJSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("token response", func(t *testing.T) {
+		redacted := RedactJSON([]byte(`{"access_token":"abc","expires_in":3599,"refresh_token":"xyz","token_type":"Bearer"}`))
+		assert.JSONEq(t,
+			`{"access_token":"[REDACTED]","expires_in":3599,"refresh_token":"[REDACTED]","token_type":"Bearer"}`,
+			string(redacted),
+		)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		redacted := RedactJSON([]byte(`[{"data":{"secret":"abc","code":{"nested":true}}}]`))
+		assert.JSONEq(t, `[{"data":{"secret":"[REDACTED]","code":{"nested":true}}}]`, string(redacted))
+	})
+
+	t.Run("not json", func(t *testing.T) {
+		assert.Equal(t, []byte("refresh_token=abc"), RedactJSON([]byte("refresh_token=abc")))
+		assert.Equal(t, []byte(`{"refresh_token":`), RedactJSON([]byte(`{"refresh_token":`)))
+		assert.Nil(t, RedactJSON(nil))
+	})
+}
+
+// TestRedactor tests a Redactor with a replacement and extra fields
+func TestRedactor(t *testing.T) {
+	t.Parallel()
+
+	redactor := NewRedactor("SCRUBBED", "client_id")
+	assert.Equal(t, "client_id=SCRUBBED&grant_type=refresh_token&refresh_token=SCRUBBED",
+		redactor.Form("grant_type=refresh_token&client_id=123&refresh_token=abc"))
+	assert.Equal(t, "https://example.com/?client_id=SCRUBBED&state=xyz",
+		redactor.URL("https://example.com/?state=xyz&client_id=123"))
+
+	redacted, ok := redactor.JSON([]byte(`{"access_token":"abc","client_id":"123","expires_in":3599}`))
+	assert.True(t, ok)
+	assert.JSONEq(t, `{"access_token":"SCRUBBED","client_id":"SCRUBBED","expires_in":3599}`, string(redacted))
+
+	redacted, ok = redactor.JSON([]byte("client_id=123"))
+	assert.False(t, ok)
+	assert.Equal(t, []byte("client_id=123"), redacted)
+
+	// The default fields are not changed
+	assert.Equal(t, "client_id=123", RedactForm("client_id=123"))
+}
+
+// TestRedactHeader tests the method RedactHeader()
+func TestRedactHeader(t *testing.T) {
+	t.Parallel()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+testSecretAccessToken)
+	header.Set("Proxy-Authorization", "secret")
+	header.Set("Cookie", "session=abc")
+	header.Set("User-Agent", defaultUserAgent)
+
+	redacted := RedactHeader(header)
+	assert.Equal(t, "Bearer "+Redacted, redacted.Get("Authorization"))
+	assert.Equal(t, Redacted, redacted.Get("Proxy-Authorization"))
+	assert.Equal(t, Redacted, redacted.Get("Cookie"))
+	assert.Equal(t, defaultUserAgent, redacted.Get("User-Agent"))
+
+	// The original is not changed
+	assert.Equal(t, "Bearer "+testSecretAccessToken, header.Get("Authorization"))
+}
+
+// TestRequestResponse_Redact tests the method Redact()
+func TestRequestResponse_Redact(t *testing.T) {
+	t.Parallel()
+
+	response := &RequestResponse{
+		BodyContents: []byte(`{"access_token":"abc"}`),
+		Method:       http.MethodPost,
+		PostData:     "refresh_token=abc",
+		StatusCode:   http.StatusOK,
+		URL:          "https://example.com/?code=abc",
+	}
+	redacted := response.Redact()
+	assert.JSONEq(t, `{"access_token":"[REDACTED]"}`, string(redacted.BodyContents))
+	assert.Equal(t, "refresh_token=%5BREDACTED%5D", redacted.PostData)
+	assert.Equal(t, "https://example.com/?code=%5BREDACTED%5D", redacted.URL)
+	assert.Equal(t, http.StatusOK, redacted.StatusCode)
+
+	// The original is not changed
+	assert.Equal(t, `{"access_token":"abc"}`, string(response.BodyContents))
+	assert.Equal(t, "refresh_token=abc", response.PostData)
+
+	// The code of an API error is kept (only the authorization code in the form and query is masked)
+	response = &RequestResponse{
+		BodyContents: []byte(`{"errors":[{"code":"invalid_grant","detail":"Invalid authorization code"}]}`),
+		PostData:     "code=abc&grant_type=authorization_code",
+		StatusCode:   http.StatusBadRequest,
+	}
+	redacted = response.Redact()
+	assert.JSONEq(t, `{"errors":[{"code":"invalid_grant","detail":"Invalid authorization code"}]}`,
+		string(redacted.BodyContents))
+	assert.Equal(t, "code=%5BREDACTED%5D&grant_type=authorization_code", redacted.PostData)
+}
+
+// mockHTTPEchoSecrets for mocking requests (the error detail echoes the refresh token that was sent)
+type mockHTTPEchoSecrets struct{}
+
+// Do is a mock http request
+func (m *mockHTTPEchoSecrets) Do(req *http.Request) (*http.Response, error) {
+	body, _ := ioutil.ReadAll(req.Body)
+	form, _ := url.ParseQuery(string(body))
+	return &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Body: ioutil.NopCloser(bytes.NewBufferString(fmt.Sprintf(
+			`{"errors":[{"status":400,"title":"Bad Request","detail":"Invalid grant: refresh token %s has expired"}]}`,
+			form.Get("refresh_token"),
+		))),
+	}, nil
+}
+
+// mockHTTPTransportError for mocking requests (fails with a transport error including the URL)
+type mockHTTPTransportError struct{}
+
+// Do is a mock http request
+func (m *mockHTTPTransportError) Do(req *http.Request) (*http.Response, error) {
+	return nil, &url.Error{Op: "Get", URL: req.URL.String(), Err: errors.New("connection refused")}
+}
+
+// TestClient_Redaction tests the redaction of secrets by the Client
+func TestClient_Redaction(t *testing.T) {
+	t.Parallel()
+
+	newRedactionClient := func(httpClient HTTPInterface, disable bool) (*Client, *[]*ResponseInfo) {
+		var infos []*ResponseInfo
+		options := DefaultClientOptions()
+		options.DisableRedaction = disable
+		options.AfterResponse = []AfterResponseHook{func(info *ResponseInfo) { infos = append(infos, info) }}
+		client := NewClient(options, nil, nil)
+		client.SetHTTPClient(httpClient)
+		return client, &infos
+	}
+
+	t.Run("token request", func(t *testing.T) {
+		client, infos := newRedactionClient(&mockHTTPEchoSecrets{}, false)
+		_, err := client.RefreshAccessToken(context.Background(), "client-id", testSecretRefreshToken)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+		assert.NotContains(t, err.Error(), testSecretRefreshToken)
+		assert.Contains(t, err.Error(), "refresh token "+Redacted+" has expired")
+
+		require.Len(t, *infos, 1)
+		info := (*infos)[0]
+		assert.NotContains(t, info.Response.PostData, testSecretRefreshToken)
+		assert.Contains(t, info.Response.PostData, "client_id=client-id")
+		assert.NotContains(t, string(info.Response.BodyContents), testSecretRefreshToken)
+		assert.NotContains(t, info.Response.Error.Error(), testSecretRefreshToken)
+	})
+
+	t.Run("token response", func(t *testing.T) {
+		client, infos := newRedactionClient(&mockHTTPRefreshAccessToken{}, false)
+		response, err := client.RefreshAccessToken(context.Background(), "client-id", testSecretRefreshToken)
+		require.NoError(t, err)
+		require.NotNil(t, response)
+
+		// The caller gets the tokens, the hooks do not
+		assert.NotEqual(t, Redacted, response.AccessToken)
+		require.Len(t, *infos, 1)
+		assert.NotContains(t, string((*infos)[0].Response.BodyContents), response.AccessToken)
+		assert.NotContains(t, string((*infos)[0].Response.BodyContents), response.RefreshToken)
+	})
+
+	t.Run("bearer token", func(t *testing.T) {
+		client, infos := newRedactionClient(&mockHTTPGetUserIdentity{}, false)
+		_, err := client.GetUserIdentity(context.Background(), testSecretAccessToken)
+		require.NoError(t, err)
+		require.Len(t, *infos, 1)
+		assert.Equal(t, "Bearer "+Redacted, (*infos)[0].Request.Header.Get("Authorization"))
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		client, _ := newRedactionClient(&mockHTTPTransportError{}, false)
+		client.Environment.APIURL = "http://localhost/?access_token=" + testSecretAccessToken + "&"
+		_, err := client.GetUserIdentity(context.Background(), testSecretAccessToken)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), testSecretAccessToken)
+		assert.Contains(t, err.Error(), "connection refused")
+	})
+
+	t.Run("disable redaction", func(t *testing.T) {
+		client, infos := newRedactionClient(&mockHTTPEchoSecrets{}, true)
+		_, err := client.RefreshAccessToken(context.Background(), "client-id", testSecretRefreshToken)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), testSecretRefreshToken)
+		require.Len(t, *infos, 1)
+		assert.Contains(t, (*infos)[0].Response.PostData, testSecretRefreshToken)
+
+		client, infos = newRedactionClient(&mockHTTPGetUserIdentity{}, true)
+		_, err = client.GetUserIdentity(context.Background(), testSecretAccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer "+testSecretAccessToken, (*infos)[0].Request.Header.Get("Authorization"))
+	})
+}
+
+// TestRedactSecrets tests the method redactSecrets()
+func TestRedactSecrets(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "token "+Redacted+" is invalid", redactSecrets("token "+testSecretAccessToken+" is invalid",
+		[]string{testSecretAccessToken}))
+
+	// Short values are not searched for (they would mask common words)
+	assert.Equal(t, "authorization code has expired", redactSecrets("authorization code has expired",
+		[]string{"code", ""}))
+}
+
+// ExampleRedactForm example using RedactForm()
+func ExampleRedactForm() {
+	fmt.Print(RedactForm("grant_type=refresh_token&refresh_token=e7cb3bac6"))
+	// Output:grant_type=refresh_token&refresh_token=%5BREDACTED%5D
+}
+
+// BenchmarkRedactJSON benchmarks the method RedactJSON()
+func BenchmarkRedactJSON(b *testing.B) {
+	body := []byte(`{"access_token":"abc","expires_in":3599,"refresh_token":"xyz","token_type":"Bearer"}`)
+	for i := 0; i < b.N; i++ {
+		_ = RedactJSON(body)
+	}
+}
@@ -36,20 +36,24 @@ func (c *Client) RefreshAccessToken(ctx context.Context, clientID, accessToken s
 		&httpPayload{
 			Data: `grant_type=` + grantTypeRefreshAccessToken + `&client_id=` + clientID +
 				`&refresh_token=` + accessToken,
+			Endpoint:       "RefreshAccessToken",
 			ExpectedStatus: http.StatusOK,
 			Method:         http.MethodPost,
-			URL:            endpointToken,
+			URL:            c.oauthURL(endpointToken),
 		},
 	)
 
 	// Error in request?
 	if response.Error != nil {
+		c.metrics().ObserveTokenRefresh(response.Error)
 		return nil, response.Error
 	}
 
 	// Create the response
 	refreshTokenResponse := new(RefreshTokenResponse)
-	if err := json.Unmarshal(response.BodyContents, &refreshTokenResponse); err != nil {
+	err := json.Unmarshal(response.BodyContents, &refreshTokenResponse)
+	c.metrics().ObserveTokenRefresh(err)
+	if err != nil {
 		return nil, err
 	}
 	return refreshTokenResponse, nil
@@ -2,27 +2,29 @@ package moneybutton
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // RequestResponse is the response from a request
 type RequestResponse struct {
-	BodyContents []byte `json:"body_contents"` // Raw body response
-	Error        error  `json:"error"`         // If an error occurs
-	Method       string `json:"method"`        // Method is the HTTP method used
-	PostData     string `json:"post_data"`     // PostData is the post data submitted if POST/PUT request
-	StatusCode   int    `json:"status_code"`   // StatusCode is the last code from the request
-	URL          string `json:"url"`           // URL is used for the request
+	BodyContents []byte   `json:"body_contents"` // Raw body response (use Redact() before logging)
+	Error        error    `json:"error"`         // If an error occurs (secrets are redacted)
+	Method       string   `json:"method"`        // Method is the HTTP method used
+	PostData     string   `json:"post_data"`     // PostData is the post data submitted if POST/PUT request (secrets are redacted)
+	StatusCode   int      `json:"status_code"`   // StatusCode is the last code from the request
+	URL          string   `json:"url"`           // URL is used for the request
+	secrets      []string // Tokens and codes sent with the request (masked by Redact())
 }
 
 // httpPayload is used for a httpRequest
 type httpPayload struct {
 	Data           string `json:"data"`
+	Endpoint       string `json:"endpoint"`
 	ExpectedStatus int    `json:"expected_status"`
 	Method         string `json:"method"`
 	Token          string `json:"token"`
@@ -39,10 +41,68 @@ func httpRequest(ctx context.Context, client *Client,
 	// Start the response
 	response = new(RequestResponse)
 
+	// Start the span (the context has the trace parent of the span)
+	var span Span
+	ctx, span = client.startSpan(ctx, payload.Endpoint)
+
+	// Log the request, record the metrics, call the after response hooks and end the span on every outcome
+	var request *http.Request
+	var sent bool
+	start := time.Now()
+	ctx, attempts := withAttemptCounter(ctx)
+	redact := !client.Options.DisableRedaction
+	if len(client.Options.AfterResponse) > 0 || client.Options.Logger != nil ||
+		client.Options.Metrics != nil || span != nil {
+		defer func() {
+			info := &ResponseInfo{
+				Attempts:   int(atomic.LoadInt32(attempts)),
+				Duration:   time.Since(start),
+				Endpoint:   payload.Endpoint,
+				Method:     payload.Method,
+				Request:    request,
+				Response:   response,
+				StatusCode: response.StatusCode,
+				URL:        payload.URL,
+			}
+			if sent && info.Attempts == 0 {
+				info.Attempts = 1 // Custom HTTP client (no retries)
+			}
+			if redact {
+				info.Response = response.Redact()
+				info.URL = info.Response.URL
+				if request != nil {
+					info.Request = request.Clone(request.Context())
+					info.Request.Header = RedactHeader(request.Header)
+				}
+			}
+			logRequest(ctx, client.Options.Logger, info)
+			client.metrics().ObserveRequest(info)
+			for _, hook := range client.Options.AfterResponse {
+				hook(info)
+			}
+			if span != nil {
+				endSpan(span, info)
+			}
+		}()
+	}
+
+	// Mask the secrets in the error message (runs before the after response hooks)
+	response.secrets = payloadSecrets(payload)
+	if redact {
+		defer func() {
+			if response.Error != nil {
+				response.Error = redactError(response.Error, response.secrets)
+			}
+		}()
+	}
+
 	// Add post data if applicable
 	if payload.Method == http.MethodPost || payload.Method == http.MethodPut {
 		bodyReader = strings.NewReader(payload.Data)
 		response.PostData = payload.Data
+		if redact {
+			response.PostData = RedactForm(payload.Data)
+		}
 	}
 
 	// Store for debugging purposes
@@ -50,7 +110,6 @@ func httpRequest(ctx context.Context, client *Client,
 	response.URL = payload.URL
 
 	// Start the request
-	var request *http.Request
 	if request, response.Error = http.NewRequestWithContext(
 		ctx, payload.Method, payload.URL, bodyReader,
 	); response.Error != nil {
@@ -70,9 +129,24 @@ func httpRequest(ctx context.Context, client *Client,
 		request.Header.Set("Authorization", authHeaderBearer+" "+payload.Token)
 	}
 
-	// Fire the http request
+	// Propagate the trace context (W3C traceparent)
+	if traceParent, ok := TraceParentFromContext(ctx); ok && traceParent.IsValid() {
+		request.Header.Set(headerTraceParent, traceParent.String())
+	}
+
+	// Run the before request hooks (in order, an error cancels the request)
+	for _, hook := range client.Options.BeforeRequest {
+		if response.Error = hook(request); response.Error != nil {
+			return
+		}
+	}
+
+	// Fire the http request (through the middleware)
 	var resp *http.Response
-	if resp, response.Error = client.httpClient.Do(request); response.Error != nil {
+	sent = true
+	if resp, response.Error = chainMiddleware(
+		client.httpClient, client.Options.Middleware,
+	).Do(request); response.Error != nil {
 		if resp != nil {
 			response.StatusCode = resp.StatusCode
 		}
@@ -94,27 +168,7 @@ func httpRequest(ctx context.Context, client *Client,
 
 	// Status does not match as expected
 	if resp.StatusCode != payload.ExpectedStatus {
-
-		// Set the error message (return 1 for now)
-		if len(response.BodyContents) > 0 {
-			errorMsg := new(errorResponse)
-			if response.Error = json.Unmarshal(
-				response.BodyContents, &errorMsg,
-			); response.Error != nil {
-				return
-			}
-			// todo: this needs some love (supporting multiple errors)
-			errString := ""
-			for _, err := range errorMsg.Errors {
-				errString += "error: " + err.Detail + " "
-			}
-
-			response.Error = fmt.Errorf("%s", errString)
-			return
-		}
-
-		// No error message found, set default error message
-		response.Error = fmt.Errorf("request failed with status code: %d", resp.StatusCode)
+		response.Error = newAPIError(response)
 		return
 	}
 
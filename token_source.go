@@ -0,0 +1,189 @@
+package moneybutton
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TokenSource holds an access/refresh token pair and will refresh the access token
+// (using RefreshAccessToken) before it expires
+//
+// A TokenSource is safe for concurrent use, concurrent refreshes are deduplicated
+type TokenSource struct {
+	client     *Client               // Client used for refreshing the access token
+	clientID   string                // The oAuth client ID
+	expirySkew time.Duration         // How early to refresh before the token expires
+	inFlight   *tokenRefresh         // Current refresh (if any)
+	mu         sync.Mutex            // Guards expirySkew, inFlight and token
+	now        func() time.Time      // Clock (replaced in tests)
+	onRefresh  TokenRefreshHook      // Called after every refresh (IE: saving to a TokenStore)
+	token      *RefreshTokenResponse // The current token
+}
+
+// TokenRefreshHook is called with the new token after every successful refresh
+type TokenRefreshHook func(ctx context.Context, token *RefreshTokenResponse) error
+
+// tokenRefresh is a single refresh that is shared between concurrent callers
+type tokenRefresh struct {
+	done  chan struct{}
+	err   error
+	token *RefreshTokenResponse
+}
+
+// NewTokenSource will create a new TokenSource from an existing token (IE: from GetRefreshToken)
+//
+// If the token does not have an Expiry, it is computed from ExpiresIn (relative to now)
+func (c *Client) NewTokenSource(clientID string, token *RefreshTokenResponse) (*TokenSource, error) {
+
+	// Check required parameters
+	if len(clientID) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "clientID")
+	} else if token == nil || len(token.RefreshToken) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "token")
+	}
+
+	t := &TokenSource{
+		client:     c,
+		clientID:   clientID,
+		expirySkew: defaultTokenExpirySkew,
+		now:        time.Now,
+	}
+	t.token = tokenWithExpiry(token, t.now())
+	return t, nil
+}
+
+// SetExpirySkew will set how early the access token is refreshed before it expires
+func (t *TokenSource) SetExpirySkew(skew time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if skew < 0 {
+		skew = 0
+	}
+	t.expirySkew = skew
+}
+
+// SetOnRefresh will set the hook that is called after every refresh
+//
+// If the hook fails, the new token is still used but the error is returned to the caller
+func (t *TokenSource) SetOnRefresh(hook TokenRefreshHook) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onRefresh = hook
+}
+
+// AccessToken will return a valid access token (refreshing it if needed)
+//
+// Use this in place of a raw access token (IE: GetUserIdentity, GetProfile)
+func (t *TokenSource) AccessToken(ctx context.Context) (string, error) {
+	token, err := t.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// Token will return a copy of the current token (refreshing it if needed)
+func (t *TokenSource) Token(ctx context.Context) (*RefreshTokenResponse, error) {
+	t.mu.Lock()
+	if !t.expired(t.token) {
+		token := *t.token
+		t.mu.Unlock()
+		return &token, nil
+	}
+	return t.refresh(ctx)
+}
+
+// Refresh will force a refresh of the access token (even if it has not expired)
+func (t *TokenSource) Refresh(ctx context.Context) (*RefreshTokenResponse, error) {
+	t.mu.Lock()
+	return t.refresh(ctx)
+}
+
+// refresh will start (or join) a refresh of the access token
+//
+// Must be called with the lock held, the lock is released before returning
+func (t *TokenSource) refresh(ctx context.Context) (*RefreshTokenResponse, error) {
+
+	// Join the refresh that is already running
+	current := t.inFlight
+	if current == nil {
+		current = &tokenRefresh{done: make(chan struct{})}
+		t.inFlight = current
+		refreshToken := t.token.RefreshToken
+
+		// The refresh is shared, it must not fail because the caller that started it gave up
+		refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultTokenRefreshTimeout)
+		go func() {
+			defer cancel()
+			t.doRefresh(refreshCtx, current, refreshToken)
+		}()
+	}
+	t.mu.Unlock()
+
+	// Wait for the refresh or the context (only this caller gives up on cancel)
+	select {
+	case <-current.done:
+		if current.err != nil {
+			return nil, current.err
+		}
+		token := *current.token
+		return &token, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// doRefresh will fire the refresh request, store the new token and run the refresh hook
+func (t *TokenSource) doRefresh(ctx context.Context, current *tokenRefresh, refreshToken string) {
+	defer close(current.done)
+
+	token, err := t.client.RefreshAccessToken(ctx, t.clientID, refreshToken)
+
+	t.mu.Lock()
+	t.inFlight = nil
+	if err != nil {
+		t.mu.Unlock()
+		current.err = err
+		return
+	}
+
+	// Keep the existing refresh token if a new one was not issued
+	if len(token.RefreshToken) == 0 {
+		token.RefreshToken = refreshToken
+	}
+	t.token = tokenWithExpiry(token, t.now())
+	current.token = t.token
+	hook := t.onRefresh
+	t.mu.Unlock()
+
+	// Fire the hook (IE: write back to the store)
+	if hook != nil {
+		tokenCopy := *current.token
+		if err = hook(ctx, &tokenCopy); err != nil {
+			current.err = fmt.Errorf("failed to run token refresh hook: %w", err)
+		}
+	}
+}
+
+// tokenWithExpiry will return a copy of the token with the absolute expiry set (from ExpiresIn)
+func tokenWithExpiry(token *RefreshTokenResponse, now time.Time) *RefreshTokenResponse {
+	tokenCopy := *token
+	if tokenCopy.Expiry.IsZero() && tokenCopy.ExpiresIn > 0 {
+		tokenCopy.Expiry = now.Add(time.Duration(tokenCopy.ExpiresIn) * time.Second)
+	}
+	return &tokenCopy
+}
+
+// expired will return true if the access token is missing or within the expiry skew
+//
+// A token without an expiry is assumed to be valid
+func (t *TokenSource) expired(token *RefreshTokenResponse) bool {
+	if len(token.AccessToken) == 0 {
+		return true
+	} else if token.Expiry.IsZero() {
+		return false
+	}
+	return !t.now().Add(t.expirySkew).Before(token.Expiry)
+}
@@ -0,0 +1,286 @@
+package moneybutton
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHTTPTokenSource for mocking refresh requests (counts the requests)
+type mockHTTPTokenSource struct {
+	delay    time.Duration
+	requests int32
+}
+
+// Do is a mock http request
+func (m *mockHTTPTokenSource) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+	resp.StatusCode = http.StatusBadRequest
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	count := atomic.AddInt32(&m.requests, 1)
+	time.Sleep(m.delay)
+
+	if req.URL.String() == OauthURL+endpointToken {
+		resp.StatusCode = http.StatusOK
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(fmt.Sprintf(
+			`{"access_token":"access-%d","token_type":"Bearer","expires_in":3600,"scope":"%s","refresh_token":"refresh-%d"}`,
+			count, PermissionsIdentity, count,
+		))))
+	}
+
+	// Default is valid
+	return resp, nil
+}
+
+// mockHTTPTokenSourceContext for mocking slow refresh requests that respect the request context
+type mockHTTPTokenSourceContext struct {
+	delay    time.Duration
+	requests int32
+}
+
+// Do is a mock http request
+func (m *mockHTTPTokenSourceContext) Do(req *http.Request) (*http.Response, error) {
+	count := atomic.AddInt32(&m.requests, 1)
+	select {
+	case <-time.After(m.delay):
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body: ioutil.NopCloser(bytes.NewBufferString(fmt.Sprintf(
+			`{"access_token":"access-%d","token_type":"Bearer","expires_in":3600,"refresh_token":"refresh-%d"}`,
+			count, count,
+		))),
+	}, nil
+}
+
+// newTestTokenSource will return a token source with a fixed clock
+func newTestTokenSource(t *testing.T, httpClient HTTPInterface, now time.Time,
+	token *RefreshTokenResponse) *TokenSource {
+	client := newTestClient(httpClient)
+	ts, err := client.NewTokenSource("1234567", token)
+	require.NoError(t, err)
+	ts.now = func() time.Time { return now }
+	return ts
+}
+
+// TestClient_NewTokenSource tests the method NewTokenSource()
+func TestClient_NewTokenSource(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing client id", func(t *testing.T) {
+		client := newTestClient(&mockHTTPTokenSource{})
+		ts, err := client.NewTokenSource("", &RefreshTokenResponse{RefreshToken: "refresh"})
+		assert.Error(t, err)
+		assert.Nil(t, ts)
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPTokenSource{})
+		ts, err := client.NewTokenSource("1234567", nil)
+		assert.Error(t, err)
+		assert.Nil(t, ts)
+	})
+
+	t.Run("missing refresh token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPTokenSource{})
+		ts, err := client.NewTokenSource("1234567", &RefreshTokenResponse{AccessToken: "access"})
+		assert.Error(t, err)
+		assert.Nil(t, ts)
+	})
+
+	t.Run("computes expiry", func(t *testing.T) {
+		client := newTestClient(&mockHTTPTokenSource{})
+		before := time.Now()
+		ts, err := client.NewTokenSource("1234567", &RefreshTokenResponse{
+			AccessToken: "access", ExpiresIn: 3600, RefreshToken: "refresh",
+		})
+		require.NoError(t, err)
+		assert.False(t, ts.token.Expiry.Before(before.Add(time.Hour)))
+		assert.False(t, ts.token.Expiry.After(time.Now().Add(time.Hour)))
+	})
+
+	t.Run("keeps existing expiry", func(t *testing.T) {
+		client := newTestClient(&mockHTTPTokenSource{})
+		expiry := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+		ts, err := client.NewTokenSource("1234567", &RefreshTokenResponse{
+			AccessToken: "access", ExpiresIn: 3600, Expiry: expiry, RefreshToken: "refresh",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, expiry, ts.token.Expiry)
+	})
+}
+
+// TestTokenSource_AccessToken tests the method AccessToken()
+func TestTokenSource_AccessToken(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("valid token is not refreshed", func(t *testing.T) {
+		mock := &mockHTTPTokenSource{}
+		ts := newTestTokenSource(t, mock, now, &RefreshTokenResponse{
+			AccessToken: "access", Expiry: now.Add(time.Hour), RefreshToken: "refresh",
+		})
+		accessToken, err := ts.AccessToken(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "access", accessToken)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&mock.requests))
+	})
+
+	t.Run("token without expiry is not refreshed", func(t *testing.T) {
+		mock := &mockHTTPTokenSource{}
+		ts := newTestTokenSource(t, mock, now, &RefreshTokenResponse{
+			AccessToken: "access", RefreshToken: "refresh",
+		})
+		accessToken, err := ts.AccessToken(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "access", accessToken)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&mock.requests))
+	})
+
+	t.Run("expired token is refreshed", func(t *testing.T) {
+		mock := &mockHTTPTokenSource{}
+		ts := newTestTokenSource(t, mock, now, &RefreshTokenResponse{
+			AccessToken: "access", Expiry: now.Add(-time.Minute), RefreshToken: "refresh",
+		})
+		accessToken, err := ts.AccessToken(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "access-1", accessToken)
+
+		token, err := ts.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "refresh-1", token.RefreshToken)
+		assert.Equal(t, now.Add(time.Hour), token.Expiry)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&mock.requests))
+	})
+
+	t.Run("token within skew is refreshed", func(t *testing.T) {
+		mock := &mockHTTPTokenSource{}
+		ts := newTestTokenSource(t, mock, now, &RefreshTokenResponse{
+			AccessToken: "access", Expiry: now.Add(30 * time.Second), RefreshToken: "refresh",
+		})
+		accessToken, err := ts.AccessToken(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "access-1", accessToken)
+	})
+
+	t.Run("custom skew", func(t *testing.T) {
+		mock := &mockHTTPTokenSource{}
+		ts := newTestTokenSource(t, mock, now, &RefreshTokenResponse{
+			AccessToken: "access", Expiry: now.Add(30 * time.Second), RefreshToken: "refresh",
+		})
+		ts.SetExpirySkew(10 * time.Second)
+		accessToken, err := ts.AccessToken(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "access", accessToken)
+
+		ts.SetExpirySkew(5 * time.Minute)
+		accessToken, err = ts.AccessToken(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "access-1", accessToken)
+	})
+
+	t.Run("refresh error", func(t *testing.T) {
+		ts := newTestTokenSource(t, &mockHTTPAPIError{}, now, &RefreshTokenResponse{
+			AccessToken: "access", Expiry: now.Add(-time.Minute), RefreshToken: "refresh",
+		})
+		accessToken, err := ts.AccessToken(context.Background())
+		assert.Error(t, err)
+		assert.Equal(t, "", accessToken)
+
+		// The old token is kept
+		assert.Equal(t, "refresh", ts.token.RefreshToken)
+	})
+
+	t.Run("concurrent refreshes are deduplicated", func(t *testing.T) {
+		mock := &mockHTTPTokenSource{delay: 50 * time.Millisecond}
+		ts := newTestTokenSource(t, mock, now, &RefreshTokenResponse{
+			AccessToken: "access", Expiry: now.Add(-time.Minute), RefreshToken: "refresh",
+		})
+
+		var wg sync.WaitGroup
+		tokens := make([]string, 10)
+		for i := range tokens {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				tokens[i], _ = ts.AccessToken(context.Background())
+			}(i)
+		}
+		wg.Wait()
+
+		for _, token := range tokens {
+			assert.Equal(t, "access-1", token)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&mock.requests))
+	})
+
+	t.Run("context canceled while waiting", func(t *testing.T) {
+		mock := &mockHTTPTokenSource{delay: 50 * time.Millisecond}
+		ts := newTestTokenSource(t, mock, now, &RefreshTokenResponse{
+			AccessToken: "access", Expiry: now.Add(-time.Minute), RefreshToken: "refresh",
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		accessToken, err := ts.AccessToken(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, "", accessToken)
+	})
+
+	t.Run("caller that started the refresh times out", func(t *testing.T) {
+		mock := &mockHTTPTokenSourceContext{delay: 100 * time.Millisecond}
+		ts := newTestTokenSource(t, mock, now, &RefreshTokenResponse{
+			AccessToken: "access", Expiry: now.Add(-time.Minute), RefreshToken: "refresh",
+		})
+
+		// Caller A starts the refresh and gives up
+		errA := make(chan error, 1)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			_, err := ts.AccessToken(ctx)
+			errA <- err
+		}()
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&mock.requests) == 1 }, time.Second, time.Millisecond)
+
+		// Caller B joins the same refresh and still gets the token
+		accessToken, err := ts.AccessToken(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "access-1", accessToken)
+		assert.ErrorIs(t, <-errA, context.DeadlineExceeded)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&mock.requests))
+	})
+}
+
+// TestTokenSource_Refresh tests the method Refresh()
+func TestTokenSource_Refresh(t *testing.T) {
+	t.Parallel()
+
+	t.Run("forces a refresh", func(t *testing.T) {
+		now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+		mock := &mockHTTPTokenSource{}
+		ts := newTestTokenSource(t, mock, now, &RefreshTokenResponse{
+			AccessToken: "access", Expiry: now.Add(time.Hour), RefreshToken: "refresh",
+		})
+		token, err := ts.Refresh(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "access-1", token.AccessToken)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&mock.requests))
+	})
+}
@@ -0,0 +1,205 @@
+package moneybutton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrTokenNotFound is returned by a TokenStore when there is no token for the user
+var ErrTokenNotFound = errors.New("token not found")
+
+// TokenStore is used for persisting tokens (keyed by the user ID from GetUserIdentity)
+type TokenStore interface {
+	Delete(ctx context.Context, userID string) error
+	Get(ctx context.Context, userID string) (*RefreshTokenResponse, error)
+	Put(ctx context.Context, userID string, token *RefreshTokenResponse) error
+}
+
+// MemoryTokenStore is an in-memory TokenStore (useful for testing or short-lived processes)
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string]*RefreshTokenResponse
+}
+
+// NewMemoryTokenStore will create a new in-memory TokenStore
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: make(map[string]*RefreshTokenResponse)}
+}
+
+// Delete will remove the token for the user
+func (m *MemoryTokenStore) Delete(_ context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.tokens, userID)
+	return nil
+}
+
+// Get will return a copy of the token for the user (or ErrTokenNotFound)
+func (m *MemoryTokenStore) Get(_ context.Context, userID string) (*RefreshTokenResponse, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	token, ok := m.tokens[userID]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	tokenCopy := *token
+	return &tokenCopy, nil
+}
+
+// Put will store a copy of the token for the user
+func (m *MemoryTokenStore) Put(_ context.Context, userID string, token *RefreshTokenResponse) error {
+	if err := checkTokenStorePut(userID, token); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tokenCopy := *token
+	m.tokens[userID] = &tokenCopy
+	return nil
+}
+
+// FileTokenStore is a TokenStore that persists all tokens to a single JSON file
+//
+// Writes are atomic (temp file and rename), the file is only readable by the current user
+type FileTokenStore struct {
+	filename string
+	mu       sync.Mutex
+}
+
+// NewFileTokenStore will create a new TokenStore using the given JSON file
+// (the file is created on the first Put)
+func NewFileTokenStore(filename string) (*FileTokenStore, error) {
+	if len(filename) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "filename")
+	}
+	return &FileTokenStore{filename: filename}, nil
+}
+
+// Delete will remove the token for the user
+func (f *FileTokenStore) Delete(_ context.Context, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tokens, err := f.load()
+	if err != nil {
+		return err
+	} else if _, ok := tokens[userID]; !ok {
+		return nil
+	}
+	delete(tokens, userID)
+	return writeJSONFileAtomic(f.filename, tokens)
+}
+
+// Get will return the token for the user (or ErrTokenNotFound)
+func (f *FileTokenStore) Get(_ context.Context, userID string) (*RefreshTokenResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tokens, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	token, ok := tokens[userID]
+	if !ok || token == nil {
+		return nil, ErrTokenNotFound
+	}
+	return token, nil
+}
+
+// Put will store the token for the user
+func (f *FileTokenStore) Put(_ context.Context, userID string, token *RefreshTokenResponse) error {
+	if err := checkTokenStorePut(userID, token); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	tokens, err := f.load()
+	if err != nil {
+		return err
+	}
+	tokens[userID] = token
+	return writeJSONFileAtomic(f.filename, tokens)
+}
+
+// load will read all the tokens from the file
+func (f *FileTokenStore) load() (map[string]*RefreshTokenResponse, error) {
+	tokens := make(map[string]*RefreshTokenResponse)
+	if err := readJSONFile(f.filename, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// checkTokenStorePut will check the required parameters for storing a token
+func checkTokenStorePut(userID string, token *RefreshTokenResponse) error {
+	if len(userID) == 0 {
+		return fmt.Errorf("missing required parameter: %s", "userID")
+	} else if token == nil {
+		return fmt.Errorf("missing required parameter: %s", "token")
+	}
+	return nil
+}
+
+// StoreToken will look up the user (GetUserIdentity) and save the token in the store
+//
+// Use this after GetRefreshToken, the token can then be loaded with NewStoredTokenSource
+func (c *Client) StoreToken(ctx context.Context, store TokenStore,
+	token *RefreshTokenResponse) (*UserIdentity, error) {
+
+	// Check required parameters
+	if store == nil {
+		return nil, fmt.Errorf("missing required parameter: %s", "store")
+	} else if token == nil || len(token.AccessToken) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "token")
+	}
+
+	// Get the user for the token
+	identity, err := c.GetUserIdentity(ctx, token.AccessToken)
+	if err != nil {
+		return nil, err
+	} else if len(identity.UserID()) == 0 {
+		return nil, fmt.Errorf("missing user id in user identity response")
+	}
+
+	// Save the token (with an absolute expiry)
+	if err = store.Put(ctx, identity.UserID(), tokenWithExpiry(token, time.Now())); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// NewStoredTokenSource will load the user's token from the store and return a TokenSource
+// that writes every refreshed token back to the store
+func (c *Client) NewStoredTokenSource(ctx context.Context, clientID, userID string,
+	store TokenStore) (*TokenSource, error) {
+
+	// Check required parameters
+	if store == nil {
+		return nil, fmt.Errorf("missing required parameter: %s", "store")
+	} else if len(userID) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "userID")
+	}
+
+	// Load the token
+	token, err := store.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the token source
+	var ts *TokenSource
+	if ts, err = c.NewTokenSource(clientID, token); err != nil {
+		return nil, err
+	}
+
+	// Write back any refreshed tokens
+	ts.SetOnRefresh(func(ctx context.Context, token *RefreshTokenResponse) error {
+		return store.Put(ctx, userID, token)
+	})
+	return ts, nil
+}
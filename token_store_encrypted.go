@@ -0,0 +1,304 @@
+package moneybutton
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownEncryptionKey is returned when a stored token was encrypted with a key that is not loaded
+var ErrUnknownEncryptionKey = errors.New("unknown encryption key")
+
+// EncryptionKey is an AES key (16, 24 or 32 bytes) tagged with an ID (used for key rotation)
+type EncryptionKey struct {
+	ID  string `json:"id"`
+	Key []byte `json:"-"`
+}
+
+// encryptedToken is a sealed RefreshTokenResponse (AES-GCM)
+type encryptedToken struct {
+	Ciphertext []byte `json:"ciphertext"`
+	KeyID      string `json:"key_id"`
+	Nonce      []byte `json:"nonce"`
+}
+
+// EncryptedTokenStore is a TokenStore that encrypts tokens at rest with AES-GCM
+//
+// Each token is tagged with the ID of the key used, older keys can be kept for reading
+// while new tokens are always written with the current key (see: ReEncrypt)
+type EncryptedTokenStore struct {
+	current  *EncryptionKey             // Key used for all writes
+	filename string                     // JSON file (empty is in-memory only)
+	keyBytes map[string][]byte          // Copy of the key bytes by ID (to detect a reused ID)
+	keys     map[string]cipher.AEAD     // All keys by ID (current and previous)
+	mu       sync.Mutex                 // Guards all reads and writes
+	records  map[string]*encryptedToken // In-memory records (when there is no file)
+}
+
+// NewEncryptedTokenStore will create a new encrypted TokenStore
+//
+// If filename is empty, the encrypted tokens are only kept in memory.
+// Previous keys are used for reading tokens that have not been re-encrypted yet
+func NewEncryptedTokenStore(filename string, current *EncryptionKey,
+	previous ...*EncryptionKey) (*EncryptedTokenStore, error) {
+
+	// Check required parameters
+	if current == nil {
+		return nil, fmt.Errorf("missing required parameter: %s", "current")
+	}
+
+	e := &EncryptedTokenStore{
+		filename: filename,
+		keyBytes: make(map[string][]byte),
+		keys:     make(map[string]cipher.AEAD),
+		records:  make(map[string]*encryptedToken),
+	}
+
+	// Load all the keys
+	for _, key := range append([]*EncryptionKey{current}, previous...) {
+		if err := e.addKey(key); err != nil {
+			return nil, err
+		}
+	}
+	e.current = current
+	return e, nil
+}
+
+// Delete will remove the token for the user
+func (e *EncryptedTokenStore) Delete(_ context.Context, userID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	records, err := e.load()
+	if err != nil {
+		return err
+	} else if _, ok := records[userID]; !ok {
+		return nil
+	}
+	delete(records, userID)
+	return e.save(records)
+}
+
+// Get will decrypt and return the token for the user (or ErrTokenNotFound)
+func (e *EncryptedTokenStore) Get(_ context.Context, userID string) (*RefreshTokenResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	records, err := e.load()
+	if err != nil {
+		return nil, err
+	}
+	record, ok := records[userID]
+	if !ok || record == nil {
+		return nil, ErrTokenNotFound
+	}
+	return e.open(userID, record)
+}
+
+// Put will encrypt (with the current key) and store the token for the user
+func (e *EncryptedTokenStore) Put(_ context.Context, userID string, token *RefreshTokenResponse) error {
+	if err := checkTokenStorePut(userID, token); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	records, err := e.load()
+	if err != nil {
+		return err
+	}
+	var record *encryptedToken
+	if record, err = e.seal(e.current, userID, token); err != nil {
+		return err
+	}
+	records[userID] = record
+	return e.save(records)
+}
+
+// ReEncrypt will make newKey the current key and re-encrypt every stored token with it
+//
+// The previous keys are kept for reading, returns the number of tokens that were re-encrypted.
+// The ID of a loaded key can be passed again (only with the same key bytes).
+// Nothing is changed if any token fails to decrypt (the current key stays the same)
+func (e *EncryptedTokenStore) ReEncrypt(_ context.Context, newKey *EncryptionKey) (int, error) {
+
+	// Check required parameters
+	if newKey == nil {
+		return 0, fmt.Errorf("missing required parameter: %s", "newKey")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Add the key (if the ID is already loaded, it must be the same key)
+	added := false
+	if loaded, ok := e.keyBytes[newKey.ID]; ok {
+		if subtle.ConstantTimeCompare(loaded, newKey.Key) != 1 {
+			return 0, fmt.Errorf("encryption key id %s is already loaded with a different key", newKey.ID)
+		}
+	} else {
+		if err := e.addKey(newKey); err != nil {
+			return 0, err
+		}
+		added = true
+	}
+
+	// Decrypt and re-encrypt all the records (into a new map, the stored records are not changed)
+	count, resealed, err := e.reseal(newKey)
+	if err != nil {
+		if added {
+			e.removeKey(newKey.ID)
+		}
+		return 0, err
+	}
+
+	// Switch the key only after everything succeeded
+	if count > 0 {
+		if err = e.save(resealed); err != nil {
+			if added {
+				e.removeKey(newKey.ID)
+			}
+			return 0, err
+		}
+	}
+	e.current = newKey
+	return count, nil
+}
+
+// reseal will return a copy of all the records with every record sealed with the key
+func (e *EncryptedTokenStore) reseal(key *EncryptionKey) (int, map[string]*encryptedToken, error) {
+	records, err := e.load()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	count := 0
+	resealed := make(map[string]*encryptedToken, len(records))
+	for userID, record := range records {
+		if record.KeyID == key.ID {
+			resealed[userID] = record
+			continue
+		}
+		var token *RefreshTokenResponse
+		if token, err = e.open(userID, record); err != nil {
+			return 0, nil, fmt.Errorf("failed to decrypt token for user %s: %w", userID, err)
+		}
+		if resealed[userID], err = e.seal(key, userID, token); err != nil {
+			return 0, nil, err
+		}
+		count++
+	}
+	return count, resealed, nil
+}
+
+// addKey will validate the key and create the AEAD cipher
+func (e *EncryptedTokenStore) addKey(key *EncryptionKey) error {
+	if key == nil {
+		return fmt.Errorf("missing required parameter: %s", "key")
+	} else if len(key.ID) == 0 {
+		return fmt.Errorf("missing required parameter: %s", "key.ID")
+	} else if _, ok := e.keys[key.ID]; ok {
+		return fmt.Errorf("duplicate encryption key id: %s", key.ID)
+	}
+
+	block, err := aes.NewCipher(key.Key)
+	if err != nil {
+		return fmt.Errorf("invalid encryption key %s: %w", key.ID, err)
+	}
+	var aead cipher.AEAD
+	if aead, err = cipher.NewGCM(block); err != nil {
+		return err
+	}
+	e.keyBytes[key.ID] = append([]byte(nil), key.Key...)
+	e.keys[key.ID] = aead
+	return nil
+}
+
+// removeKey will remove the key (IE: a key added by a failed ReEncrypt)
+func (e *EncryptedTokenStore) removeKey(keyID string) {
+	delete(e.keyBytes, keyID)
+	delete(e.keys, keyID)
+}
+
+// seal will encrypt the token with the key
+//
+// The user ID and key ID are used as additional data (a record cannot be moved to another user)
+func (e *EncryptedTokenStore) seal(key *EncryptionKey, userID string,
+	token *RefreshTokenResponse) (*encryptedToken, error) {
+	plaintext, err := json.Marshal(token)
+	if err != nil {
+		return nil, err
+	}
+
+	aead := e.keys[key.ID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &encryptedToken{
+		Ciphertext: aead.Seal(nil, nonce, plaintext, additionalData(userID, key.ID)),
+		KeyID:      key.ID,
+		Nonce:      nonce,
+	}, nil
+}
+
+// open will decrypt the token with the key it was sealed with
+func (e *EncryptedTokenStore) open(userID string, record *encryptedToken) (*RefreshTokenResponse, error) {
+	aead, ok := e.keys[record.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEncryptionKey, record.KeyID)
+	} else if len(record.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size: %d", len(record.Nonce))
+	}
+
+	plaintext, err := aead.Open(nil, record.Nonce, record.Ciphertext, additionalData(userID, record.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt token: %w", err)
+	}
+
+	token := new(RefreshTokenResponse)
+	if err = json.Unmarshal(plaintext, &token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// load will return all the encrypted records (from the file if set)
+func (e *EncryptedTokenStore) load() (map[string]*encryptedToken, error) {
+	if len(e.filename) == 0 {
+		return e.records, nil
+	}
+	records := make(map[string]*encryptedToken)
+	if err := readJSONFile(e.filename, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// save will store all the encrypted records (to the file if set)
+func (e *EncryptedTokenStore) save(records map[string]*encryptedToken) error {
+	if len(e.filename) == 0 {
+		e.records = records
+		return nil
+	}
+	return writeJSONFileAtomic(e.filename, records)
+}
+
+// additionalData is the authenticated (but not encrypted) data for a record
+//
+// Both fields are length-prefixed so IDs that contain a separator cannot collide
+func additionalData(userID, keyID string) []byte {
+	data := make([]byte, 0, 8+len(keyID)+len(userID))
+	data = binary.BigEndian.AppendUint32(data, uint32(len(keyID)))
+	data = append(data, keyID...)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(userID)))
+	return append(data, userID...)
+}
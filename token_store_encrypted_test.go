@@ -0,0 +1,251 @@
+package moneybutton
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testEncryptionKey will return a key with a repeated byte
+func testEncryptionKey(id string, b byte) *EncryptionKey {
+	return &EncryptionKey{ID: id, Key: bytes.Repeat([]byte{b}, 32)}
+}
+
+// TestNewEncryptedTokenStore tests the method NewEncryptedTokenStore()
+func TestNewEncryptedTokenStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing key", func(t *testing.T) {
+		store, err := NewEncryptedTokenStore("", nil)
+		assert.Error(t, err)
+		assert.Nil(t, store)
+	})
+
+	t.Run("missing key id", func(t *testing.T) {
+		store, err := NewEncryptedTokenStore("", testEncryptionKey("", 1))
+		assert.Error(t, err)
+		assert.Nil(t, store)
+	})
+
+	t.Run("invalid key size", func(t *testing.T) {
+		store, err := NewEncryptedTokenStore("", &EncryptionKey{ID: "key-1", Key: []byte("short")})
+		assert.Error(t, err)
+		assert.Nil(t, store)
+	})
+
+	t.Run("duplicate key id", func(t *testing.T) {
+		store, err := NewEncryptedTokenStore("", testEncryptionKey("key-1", 1), testEncryptionKey("key-1", 2))
+		assert.Error(t, err)
+		assert.Nil(t, store)
+	})
+}
+
+// TestEncryptedTokenStore tests the EncryptedTokenStore
+func TestEncryptedTokenStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("in-memory", func(t *testing.T) {
+		store, err := NewEncryptedTokenStore("", testEncryptionKey("key-1", 1))
+		require.NoError(t, err)
+		testTokenStore(t, store)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		store, err := NewEncryptedTokenStore(filepath.Join(t.TempDir(), "tokens.json"), testEncryptionKey("key-1", 1))
+		require.NoError(t, err)
+		testTokenStore(t, store)
+	})
+
+	t.Run("no plaintext on disk", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "tokens.json")
+		store, err := NewEncryptedTokenStore(filename, testEncryptionKey("key-1", 1))
+		require.NoError(t, err)
+		require.NoError(t, store.Put(context.Background(), "123", &RefreshTokenResponse{
+			AccessToken: "secret-access-token", RefreshToken: "secret-refresh-token",
+		}))
+
+		data, err := os.ReadFile(filename) //nolint:gosec // test file
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "secret-access-token")
+		assert.NotContains(t, string(data), "secret-refresh-token")
+		assert.Contains(t, string(data), `"key_id": "key-1"`)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "tokens.json")
+		store, err := NewEncryptedTokenStore(filename, testEncryptionKey("key-1", 1))
+		require.NoError(t, err)
+		require.NoError(t, store.Put(context.Background(), "123", &RefreshTokenResponse{RefreshToken: "refresh"}))
+
+		// Same key id, different key material
+		store2, err := NewEncryptedTokenStore(filename, testEncryptionKey("key-1", 2))
+		require.NoError(t, err)
+		token, err := store2.Get(context.Background(), "123")
+		assert.Error(t, err)
+		assert.Nil(t, token)
+
+		// Unknown key id
+		store3, err := NewEncryptedTokenStore(filename, testEncryptionKey("key-2", 1))
+		require.NoError(t, err)
+		token, err = store3.Get(context.Background(), "123")
+		assert.ErrorIs(t, err, ErrUnknownEncryptionKey)
+		assert.Nil(t, token)
+	})
+
+	t.Run("record cannot be moved to another user", func(t *testing.T) {
+		store, err := NewEncryptedTokenStore("", testEncryptionKey("key-1", 1))
+		require.NoError(t, err)
+		require.NoError(t, store.Put(context.Background(), "123", &RefreshTokenResponse{RefreshToken: "refresh"}))
+
+		store.records["456"] = store.records["123"]
+		token, err := store.Get(context.Background(), "456")
+		assert.Error(t, err)
+		assert.Nil(t, token)
+	})
+}
+
+// TestEncryptedTokenStore_ReEncrypt tests the method ReEncrypt()
+func TestEncryptedTokenStore_ReEncrypt(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing key", func(t *testing.T) {
+		store, err := NewEncryptedTokenStore("", testEncryptionKey("key-1", 1))
+		require.NoError(t, err)
+		count, err := store.ReEncrypt(context.Background(), nil)
+		assert.Error(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		store, err := NewEncryptedTokenStore("", testEncryptionKey("key-1", 1))
+		require.NoError(t, err)
+		count, err := store.ReEncrypt(context.Background(), &EncryptionKey{ID: "key-2"})
+		assert.Error(t, err)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("reused key id", func(t *testing.T) {
+		ctx := context.Background()
+		store, err := NewEncryptedTokenStore("", testEncryptionKey("key-1", 1), testEncryptionKey("key-2", 2))
+		require.NoError(t, err)
+		require.NoError(t, store.Put(ctx, "123", &RefreshTokenResponse{AccessToken: "access", RefreshToken: "refresh"}))
+
+		// Different key bytes are rejected (the current key stays the same)
+		count, err := store.ReEncrypt(ctx, testEncryptionKey("key-2", 3))
+		require.Error(t, err)
+		assert.Equal(t, 0, count)
+		assert.Equal(t, "key-1", store.current.ID)
+
+		// The same key bytes are accepted
+		count, err = store.ReEncrypt(ctx, testEncryptionKey("key-2", 2))
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, "key-2", store.current.ID)
+	})
+
+	t.Run("rotate keys", func(t *testing.T) {
+		ctx := context.Background()
+		filename := filepath.Join(t.TempDir(), "tokens.json")
+		oldKey := testEncryptionKey("key-1", 1)
+		newKey := testEncryptionKey("key-2", 2)
+
+		store, err := NewEncryptedTokenStore(filename, oldKey)
+		require.NoError(t, err)
+		require.NoError(t, store.Put(ctx, "123", &RefreshTokenResponse{RefreshToken: "refresh-123"}))
+		require.NoError(t, store.Put(ctx, "456", &RefreshTokenResponse{RefreshToken: "refresh-456"}))
+
+		count, err := store.ReEncrypt(ctx, newKey)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		// Running again is a no-op
+		count, err = store.ReEncrypt(ctx, newKey)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+		// Only the new key is needed now
+		store2, err := NewEncryptedTokenStore(filename, newKey)
+		require.NoError(t, err)
+		token, err := store2.Get(ctx, "123")
+		require.NoError(t, err)
+		assert.Equal(t, "refresh-123", token.RefreshToken)
+		token, err = store2.Get(ctx, "456")
+		require.NoError(t, err)
+		assert.Equal(t, "refresh-456", token.RefreshToken)
+
+		// The old key can no longer read the tokens
+		store3, err := NewEncryptedTokenStore(filename, oldKey)
+		require.NoError(t, err)
+		_, err = store3.Get(ctx, "123")
+		assert.ErrorIs(t, err, ErrUnknownEncryptionKey)
+	})
+
+	t.Run("read with previous key", func(t *testing.T) {
+		ctx := context.Background()
+		filename := filepath.Join(t.TempDir(), "tokens.json")
+		oldKey := testEncryptionKey("key-1", 1)
+		newKey := testEncryptionKey("key-2", 2)
+
+		store, err := NewEncryptedTokenStore(filename, oldKey)
+		require.NoError(t, err)
+		require.NoError(t, store.Put(ctx, "123", &RefreshTokenResponse{RefreshToken: "refresh-123"}))
+
+		// New writes use the new key, old records are still readable
+		store2, err := NewEncryptedTokenStore(filename, newKey, oldKey)
+		require.NoError(t, err)
+		require.NoError(t, store2.Put(ctx, "456", &RefreshTokenResponse{RefreshToken: "refresh-456"}))
+		token, err := store2.Get(ctx, "123")
+		require.NoError(t, err)
+		assert.Equal(t, "refresh-123", token.RefreshToken)
+
+		count, err := store2.ReEncrypt(ctx, newKey)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("failed record leaves the store unchanged", func(t *testing.T) {
+		ctx := context.Background()
+		oldKey := testEncryptionKey("key-1", 1)
+		store, err := NewEncryptedTokenStore("", oldKey)
+		require.NoError(t, err)
+		require.NoError(t, store.Put(ctx, "123", &RefreshTokenResponse{RefreshToken: "refresh-123"}))
+		require.NoError(t, store.Put(ctx, "456", &RefreshTokenResponse{RefreshToken: "refresh-456"}))
+
+		// Corrupt one record
+		corrupt := *store.records["456"]
+		corrupt.Ciphertext = append([]byte{0}, corrupt.Ciphertext[1:]...)
+		store.records["456"] = &corrupt
+		valid := store.records["123"]
+
+		count, err := store.ReEncrypt(ctx, testEncryptionKey("key-2", 2))
+		assert.Error(t, err)
+		assert.Equal(t, 0, count)
+
+		// Same key, same records, the new key was not added
+		assert.Equal(t, oldKey, store.current)
+		assert.Same(t, valid, store.records["123"])
+		assert.Equal(t, "key-1", store.records["123"].KeyID)
+		assert.NotContains(t, store.keys, "key-2")
+		token, err := store.Get(ctx, "123")
+		require.NoError(t, err)
+		assert.Equal(t, "refresh-123", token.RefreshToken)
+
+		// New writes still use the old key
+		require.NoError(t, store.Put(ctx, "789", &RefreshTokenResponse{RefreshToken: "refresh-789"}))
+		assert.Equal(t, "key-1", store.records["789"].KeyID)
+	})
+}
+
+// TestAdditionalData tests the method additionalData()
+func TestAdditionalData(t *testing.T) {
+	t.Parallel()
+
+	assert.NotEqual(t, additionalData("b:c", "a"), additionalData("c", "a:b"))
+	assert.NotEqual(t, additionalData("", "ab"), additionalData("b", "a"))
+	assert.Equal(t, additionalData("123", "key-1"), additionalData("123", "key-1"))
+}
@@ -0,0 +1,274 @@
+package moneybutton
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTokenStore will run the common TokenStore tests against a store
+func testTokenStore(t *testing.T, store TokenStore) {
+	ctx := context.Background()
+	expiry := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("missing token", func(t *testing.T) {
+		token, err := store.Get(ctx, "unknown")
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+		assert.Nil(t, token)
+	})
+
+	t.Run("put invalid parameters", func(t *testing.T) {
+		assert.Error(t, store.Put(ctx, "", &RefreshTokenResponse{}))
+		assert.Error(t, store.Put(ctx, "123", nil))
+	})
+
+	t.Run("put, get and delete", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "123", &RefreshTokenResponse{
+			AccessToken:  "access",
+			ExpiresIn:    3600,
+			Expiry:       expiry,
+			RefreshToken: "refresh",
+			Scope:        PermissionsIdentity,
+			TokenType:    authHeaderBearer,
+		}))
+
+		token, err := store.Get(ctx, "123")
+		require.NoError(t, err)
+		assert.Equal(t, "access", token.AccessToken)
+		assert.Equal(t, uint32(3600), token.ExpiresIn)
+		assert.True(t, expiry.Equal(token.Expiry))
+		assert.Equal(t, "refresh", token.RefreshToken)
+		assert.Equal(t, PermissionsIdentity, token.Scope)
+		assert.Equal(t, authHeaderBearer, token.TokenType)
+
+		// Overwrite
+		require.NoError(t, store.Put(ctx, "123", &RefreshTokenResponse{AccessToken: "access-2"}))
+		token, err = store.Get(ctx, "123")
+		require.NoError(t, err)
+		assert.Equal(t, "access-2", token.AccessToken)
+
+		require.NoError(t, store.Delete(ctx, "123"))
+		token, err = store.Get(ctx, "123")
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+		assert.Nil(t, token)
+
+		// Delete again is not an error
+		assert.NoError(t, store.Delete(ctx, "123"))
+	})
+}
+
+// TestMemoryTokenStore tests the MemoryTokenStore
+func TestMemoryTokenStore(t *testing.T) {
+	t.Parallel()
+
+	testTokenStore(t, NewMemoryTokenStore())
+
+	t.Run("returns copies", func(t *testing.T) {
+		store := NewMemoryTokenStore()
+		token := &RefreshTokenResponse{AccessToken: "access"}
+		require.NoError(t, store.Put(context.Background(), "123", token))
+		token.AccessToken = "modified"
+
+		stored, err := store.Get(context.Background(), "123")
+		require.NoError(t, err)
+		assert.Equal(t, "access", stored.AccessToken)
+	})
+}
+
+// TestFileTokenStore tests the FileTokenStore
+func TestFileTokenStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing filename", func(t *testing.T) {
+		store, err := NewFileTokenStore("")
+		assert.Error(t, err)
+		assert.Nil(t, store)
+	})
+
+	filename := filepath.Join(t.TempDir(), "tokens.json")
+	store, err := NewFileTokenStore(filename)
+	require.NoError(t, err)
+	testTokenStore(t, store)
+
+	t.Run("persists between stores", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "tokens.json")
+		store, err := NewFileTokenStore(filename)
+		require.NoError(t, err)
+		require.NoError(t, store.Put(context.Background(), "123", &RefreshTokenResponse{RefreshToken: "refresh"}))
+
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(filename)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+		}
+
+		store2, err := NewFileTokenStore(filename)
+		require.NoError(t, err)
+		token, err := store2.Get(context.Background(), "123")
+		require.NoError(t, err)
+		assert.Equal(t, "refresh", token.RefreshToken)
+
+		// No temp files are left behind
+		entries, err := os.ReadDir(filepath.Dir(filename))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "tokens.json")
+		require.NoError(t, os.WriteFile(filename, []byte("not-json"), 0o600))
+		store, err := NewFileTokenStore(filename)
+		require.NoError(t, err)
+		token, err := store.Get(context.Background(), "123")
+		assert.Error(t, err)
+		assert.Nil(t, token)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		store, err := NewFileTokenStore(filepath.Join(t.TempDir(), "missing", "tokens.json"))
+		require.NoError(t, err)
+		assert.Error(t, store.Put(context.Background(), "123", &RefreshTokenResponse{}))
+	})
+}
+
+// errorTokenStore is a TokenStore that always fails
+type errorTokenStore struct{}
+
+// Delete will always return an error
+func (e *errorTokenStore) Delete(_ context.Context, _ string) error {
+	return errors.New("store error")
+}
+
+// Get will always return an error
+func (e *errorTokenStore) Get(_ context.Context, _ string) (*RefreshTokenResponse, error) {
+	return nil, errors.New("store error")
+}
+
+// Put will always return an error
+func (e *errorTokenStore) Put(_ context.Context, _ string, _ *RefreshTokenResponse) error {
+	return errors.New("store error")
+}
+
+// TestClient_StoreToken tests the method StoreToken()
+func TestClient_StoreToken(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing store", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetUserIdentity{})
+		identity, err := client.StoreToken(context.Background(), nil, &RefreshTokenResponse{AccessToken: "access"})
+		assert.Error(t, err)
+		assert.Nil(t, identity)
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetUserIdentity{})
+		identity, err := client.StoreToken(context.Background(), NewMemoryTokenStore(), nil)
+		assert.Error(t, err)
+		assert.Nil(t, identity)
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		client := newTestClient(&mockHTTPAPIError{})
+		identity, err := client.StoreToken(context.Background(), NewMemoryTokenStore(), &RefreshTokenResponse{AccessToken: "access"})
+		assert.Error(t, err)
+		assert.Nil(t, identity)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetUserIdentity{})
+		identity, err := client.StoreToken(context.Background(), &errorTokenStore{}, &RefreshTokenResponse{AccessToken: "access"})
+		assert.Error(t, err)
+		assert.Nil(t, identity)
+	})
+
+	t.Run("valid token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetUserIdentity{})
+		store := NewMemoryTokenStore()
+		identity, err := client.StoreToken(context.Background(), store, &RefreshTokenResponse{
+			AccessToken: "access", ExpiresIn: 3600, RefreshToken: "refresh",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "123", identity.Data.ID)
+
+		token, err := store.Get(context.Background(), "123")
+		require.NoError(t, err)
+		assert.Equal(t, "refresh", token.RefreshToken)
+		assert.False(t, token.Expiry.IsZero())
+	})
+}
+
+// TestClient_NewStoredTokenSource tests the method NewStoredTokenSource()
+func TestClient_NewStoredTokenSource(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing store", func(t *testing.T) {
+		client := newTestClient(&mockHTTPTokenSource{})
+		ts, err := client.NewStoredTokenSource(context.Background(), "1234567", "123", nil)
+		assert.Error(t, err)
+		assert.Nil(t, ts)
+	})
+
+	t.Run("missing user id", func(t *testing.T) {
+		client := newTestClient(&mockHTTPTokenSource{})
+		ts, err := client.NewStoredTokenSource(context.Background(), "1234567", "", NewMemoryTokenStore())
+		assert.Error(t, err)
+		assert.Nil(t, ts)
+	})
+
+	t.Run("token not found", func(t *testing.T) {
+		client := newTestClient(&mockHTTPTokenSource{})
+		ts, err := client.NewStoredTokenSource(context.Background(), "1234567", "123", NewMemoryTokenStore())
+		assert.ErrorIs(t, err, ErrTokenNotFound)
+		assert.Nil(t, ts)
+	})
+
+	t.Run("refreshed token is written back", func(t *testing.T) {
+		ctx := context.Background()
+		store := NewMemoryTokenStore()
+		require.NoError(t, store.Put(ctx, "123", &RefreshTokenResponse{
+			AccessToken: "access", Expiry: time.Now().Add(-time.Minute), RefreshToken: "refresh",
+		}))
+
+		client := newTestClient(&mockHTTPTokenSource{})
+		ts, err := client.NewStoredTokenSource(ctx, "1234567", "123", store)
+		require.NoError(t, err)
+
+		accessToken, err := ts.AccessToken(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "access-1", accessToken)
+
+		token, err := store.Get(ctx, "123")
+		require.NoError(t, err)
+		assert.Equal(t, "access-1", token.AccessToken)
+		assert.Equal(t, "refresh-1", token.RefreshToken)
+		assert.True(t, token.Expiry.After(time.Now()))
+	})
+
+	t.Run("hook error is returned", func(t *testing.T) {
+		ctx := context.Background()
+		client := newTestClient(&mockHTTPTokenSource{})
+		ts, err := client.NewTokenSource("1234567", &RefreshTokenResponse{
+			AccessToken: "access", Expiry: time.Now().Add(-time.Minute), RefreshToken: "refresh",
+		})
+		require.NoError(t, err)
+		ts.SetOnRefresh(func(ctx context.Context, token *RefreshTokenResponse) error {
+			return (&errorTokenStore{}).Put(ctx, "123", token)
+		})
+
+		accessToken, err := ts.AccessToken(ctx)
+		assert.Error(t, err)
+		assert.Equal(t, "", accessToken)
+
+		// The new token is still used
+		accessToken, err = ts.AccessToken(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "access-1", accessToken)
+	})
+}
@@ -0,0 +1,164 @@
+package moneybutton
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Span attributes set by the Client (OpenTelemetry semantic conventions)
+const (
+	AttributeAttempts   = "moneybutton.attempts"      // Attempts made including retries
+	AttributeMethod     = "http.request.method"       // IE: GET
+	AttributeStatusCode = "http.response.status_code" // Status code (0 if no response was received)
+	AttributeURL        = "url.full"                  // Full URL of the request (secrets are redacted)
+)
+
+// headerTraceParent is the W3C trace context header
+//
+// Specs: https://www.w3.org/TR/trace-context/#traceparent-header
+const headerTraceParent = "traceparent"
+
+// ErrInvalidTraceParent is returned when a traceparent header cannot be parsed
+var ErrInvalidTraceParent = errors.New("invalid traceparent")
+
+// Tracer starts a span for every API call (IE: an adapter for OpenTelemetry or another tracing library)
+//
+// The span is named after the Client method (IE: GetProfile), the returned context should contain
+// the TraceParent of the new span (see: ContextWithTraceParent()) so it is sent to MoneyButton
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single API call started by a Tracer
+type Span interface {
+	End()                                       // Called once after the request (including failed requests)
+	RecordError(err error)                      // Called if the request failed
+	SetAttribute(key string, value interface{}) // Called with the Attribute* keys
+}
+
+// TraceParent is the W3C trace context of a span (sent in the traceparent header)
+//
+// Specs: https://www.w3.org/TR/trace-context/#traceparent-header
+type TraceParent struct {
+	Flags   byte     // Trace flags (IE: 01 is sampled)
+	SpanID  [8]byte  // ID of the span (parent-id in the header)
+	TraceID [16]byte // ID of the whole trace
+}
+
+// traceParentKey is the context key for the TraceParent
+type traceParentKey struct{}
+
+// ContextWithTraceParent will return a context with the trace parent (sent with every request using the context)
+func ContextWithTraceParent(ctx context.Context, traceParent TraceParent) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, traceParent)
+}
+
+// TraceParentFromContext will return the trace parent of the context (false if not set)
+func TraceParentFromContext(ctx context.Context) (TraceParent, bool) {
+	traceParent, ok := ctx.Value(traceParentKey{}).(TraceParent)
+	return traceParent, ok
+}
+
+// NewTraceParent will create a sampled trace parent for a new trace (random trace and span IDs)
+func NewTraceParent() TraceParent {
+	var traceParent TraceParent
+	randomID(traceParent.TraceID[:])
+	randomID(traceParent.SpanID[:])
+	traceParent.Flags = 1
+	return traceParent
+}
+
+// ParseTraceParent will parse a traceparent header (IE: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01)
+//
+// Future versions are accepted (the extra fields are ignored), the String() is always version 00
+func ParseTraceParent(header string) (TraceParent, error) {
+	var traceParent TraceParent
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return traceParent, ErrInvalidTraceParent
+	}
+	for _, part := range parts[:4] {
+		if strings.ToLower(part) != part {
+			return traceParent, ErrInvalidTraceParent
+		}
+	}
+
+	// Version 00 has exactly four fields and ff is forbidden
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(parts) != 4) {
+		return traceParent, ErrInvalidTraceParent
+	}
+
+	var flags []byte
+	if _, err = hex.Decode(traceParent.TraceID[:], []byte(parts[1])); err != nil {
+		return TraceParent{}, ErrInvalidTraceParent
+	} else if _, err = hex.Decode(traceParent.SpanID[:], []byte(parts[2])); err != nil {
+		return TraceParent{}, ErrInvalidTraceParent
+	} else if flags, err = hex.DecodeString(parts[3]); err != nil {
+		return TraceParent{}, ErrInvalidTraceParent
+	}
+	traceParent.Flags = flags[0]
+
+	if !traceParent.IsValid() {
+		return TraceParent{}, ErrInvalidTraceParent
+	}
+	return traceParent, nil
+}
+
+// IsValid will return true if the trace and span IDs are set (all zeros is invalid)
+func (t TraceParent) IsValid() bool {
+	return t.TraceID != [16]byte{} && t.SpanID != [8]byte{}
+}
+
+// Sampled will return true if the sampled flag is set
+func (t TraceParent) Sampled() bool {
+	return t.Flags&1 == 1
+}
+
+// NewChild will return the trace parent for a child span (same trace and flags, random span ID)
+func (t TraceParent) NewChild() TraceParent {
+	child := t
+	randomID(child.SpanID[:])
+	return child
+}
+
+// String will return the traceparent header value (always version 00)
+func (t TraceParent) String() string {
+	return "00-" + hex.EncodeToString(t.TraceID[:]) + "-" + hex.EncodeToString(t.SpanID[:]) + "-" +
+		hex.EncodeToString([]byte{t.Flags})
+}
+
+// randomID will fill the ID with random bytes (never all zeros)
+func randomID(id []byte) {
+	for {
+		_, _ = rand.Read(id)
+		for _, b := range id {
+			if b != 0 {
+				return
+			}
+		}
+	}
+}
+
+// startSpan will start a span for the request (nil if there is no Tracer)
+func (c *Client) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if c.Options.Tracer == nil {
+		return ctx, nil
+	}
+	return c.Options.Tracer.StartSpan(ctx, name)
+}
+
+// endSpan will record the attributes and error of the request and end the span
+func endSpan(span Span, info *ResponseInfo) {
+	span.SetAttribute(AttributeMethod, info.Method)
+	span.SetAttribute(AttributeURL, info.URL)
+	span.SetAttribute(AttributeStatusCode, info.StatusCode)
+	span.SetAttribute(AttributeAttempts, info.Attempts)
+	if info.Response.Error != nil {
+		span.RecordError(info.Response.Error)
+	}
+	span.End()
+}
@@ -0,0 +1,278 @@
+package moneybutton
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// mockTracer for tracing requests (records the spans)
+type mockTracer struct {
+	mu    sync.Mutex
+	spans []*mockSpan
+}
+
+// StartSpan will start a child span of the trace parent in the context (or a new trace)
+func (m *mockTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	traceParent, ok := TraceParentFromContext(ctx)
+	if ok {
+		traceParent = traceParent.NewChild()
+	} else {
+		traceParent = NewTraceParent()
+	}
+	span := &mockSpan{attributes: map[string]interface{}{}, name: name, traceParent: traceParent}
+	m.mu.Lock()
+	m.spans = append(m.spans, span)
+	m.mu.Unlock()
+	return ContextWithTraceParent(ctx, traceParent), span
+}
+
+// mockSpan for tracing requests (records the attributes, errors and end)
+type mockSpan struct {
+	attributes  map[string]interface{}
+	ended       int
+	errors      []error
+	name        string
+	traceParent TraceParent
+}
+
+// End will count the end
+func (m *mockSpan) End() {
+	m.ended++
+}
+
+// RecordError will record the error
+func (m *mockSpan) RecordError(err error) {
+	m.errors = append(m.errors, err)
+}
+
+// SetAttribute will record the attribute
+func (m *mockSpan) SetAttribute(key string, value interface{}) {
+	m.attributes[key] = value
+}
+
+// mockHTTPTraceParent for mocking requests (records the traceparent header)
+type mockHTTPTraceParent struct {
+	headers []string
+}
+
+// Do is a mock http request
+func (m *mockHTTPTraceParent) Do(req *http.Request) (*http.Response, error) {
+	m.headers = append(m.headers, req.Header.Get(headerTraceParent))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`{"data":{"type":"resources","id":"1"}}`)),
+	}, nil
+}
+
+// TestClient_Tracer tests the spans and trace context propagation of the Client
+func TestClient_Tracer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("span per api call", func(t *testing.T) {
+		tracer := &mockTracer{}
+		options := DefaultClientOptions()
+		options.Tracer = tracer
+		client := NewClient(options, nil, nil)
+		mock := &mockHTTPTraceParent{}
+		client.SetHTTPClient(mock)
+
+		_, err := client.GetProfile(context.Background(), "123", testSecretAccessToken)
+		require.NoError(t, err)
+
+		require.Len(t, tracer.spans, 1)
+		span := tracer.spans[0]
+		assert.Equal(t, "GetProfile", span.name)
+		assert.Equal(t, 1, span.ended)
+		assert.Empty(t, span.errors)
+		assert.Equal(t, map[string]interface{}{
+			AttributeAttempts:   1,
+			AttributeMethod:     http.MethodGet,
+			AttributeStatusCode: http.StatusOK,
+			AttributeURL:        APIURL + "users/123/profile",
+		}, span.attributes)
+
+		// The traceparent of the span is sent
+		assert.Equal(t, []string{span.traceParent.String()}, mock.headers)
+	})
+
+	t.Run("child of the trace parent in the context", func(t *testing.T) {
+		tracer := &mockTracer{}
+		options := DefaultClientOptions()
+		options.Tracer = tracer
+		client := NewClient(options, nil, nil)
+		mock := &mockHTTPTraceParent{}
+		client.SetHTTPClient(mock)
+
+		parent, err := ParseTraceParent(testTraceParent)
+		require.NoError(t, err)
+		_, err = client.GetUserIdentity(ContextWithTraceParent(context.Background(), parent), testSecretAccessToken)
+		require.NoError(t, err)
+
+		require.Len(t, mock.headers, 1)
+		sent, err := ParseTraceParent(mock.headers[0])
+		require.NoError(t, err)
+		assert.Equal(t, parent.TraceID, sent.TraceID)
+		assert.NotEqual(t, parent.SpanID, sent.SpanID)
+		assert.True(t, sent.Sampled())
+	})
+
+	t.Run("error is recorded", func(t *testing.T) {
+		tracer := &mockTracer{}
+		options := DefaultClientOptions()
+		options.Tracer = tracer
+		client := NewClient(options, nil, nil)
+		client.SetHTTPClient(&mockHTTPEchoSecrets{})
+
+		_, err := client.RefreshAccessToken(context.Background(), "client-id", testSecretRefreshToken)
+		require.Error(t, err)
+
+		require.Len(t, tracer.spans, 1)
+		span := tracer.spans[0]
+		assert.Equal(t, "RefreshAccessToken", span.name)
+		assert.Equal(t, 1, span.ended)
+		assert.Equal(t, http.StatusBadRequest, span.attributes[AttributeStatusCode])
+		require.Len(t, span.errors, 1)
+		assert.ErrorIs(t, span.errors[0], ErrInvalidRefreshToken)
+		assert.NotContains(t, span.errors[0].Error(), testSecretRefreshToken)
+	})
+
+	t.Run("trace parent without a tracer", func(t *testing.T) {
+		client := NewClient(nil, nil, nil)
+		mock := &mockHTTPTraceParent{}
+		client.SetHTTPClient(mock)
+
+		parent, err := ParseTraceParent(testTraceParent)
+		require.NoError(t, err)
+		_, err = client.GetUserIdentity(ContextWithTraceParent(context.Background(), parent), testSecretAccessToken)
+		require.NoError(t, err)
+		_, err = client.GetUserIdentity(context.Background(), testSecretAccessToken)
+		require.NoError(t, err)
+		_, err = client.GetUserIdentity(ContextWithTraceParent(context.Background(), TraceParent{}), testSecretAccessToken)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{testTraceParent, "", ""}, mock.headers)
+	})
+}
+
+// TestParseTraceParent tests the method ParseTraceParent()
+func TestParseTraceParent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header string
+		valid  bool
+	}{
+		{"valid", testTraceParent, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true},
+		{"future version with more fields", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		{"empty", "", false},
+		{"version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"version 00 with more fields", testTraceParent + "-extra", false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"short trace id", "00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01", false},
+		{"not hex", "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01", false},
+		{"invalid flags", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0z", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			traceParent, err := ParseTraceParent(test.header)
+			if test.valid {
+				require.NoError(t, err)
+				assert.True(t, traceParent.IsValid())
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidTraceParent)
+				assert.Equal(t, TraceParent{}, traceParent)
+			}
+		})
+	}
+}
+
+// TestTraceParent tests the methods of TraceParent
+func TestTraceParent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("string", func(t *testing.T) {
+		traceParent, err := ParseTraceParent(testTraceParent)
+		require.NoError(t, err)
+		assert.Equal(t, testTraceParent, traceParent.String())
+		assert.True(t, traceParent.Sampled())
+
+		traceParent, err = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-02-extra")
+		require.NoError(t, err)
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-02", traceParent.String())
+		assert.False(t, traceParent.Sampled())
+	})
+
+	t.Run("new trace parent", func(t *testing.T) {
+		first := NewTraceParent()
+		second := NewTraceParent()
+		assert.True(t, first.IsValid())
+		assert.True(t, first.Sampled())
+		assert.NotEqual(t, first.TraceID, second.TraceID)
+
+		parsed, err := ParseTraceParent(first.String())
+		require.NoError(t, err)
+		assert.Equal(t, first, parsed)
+	})
+
+	t.Run("new child", func(t *testing.T) {
+		parent := NewTraceParent()
+		child := parent.NewChild()
+		assert.Equal(t, parent.TraceID, child.TraceID)
+		assert.Equal(t, parent.Flags, child.Flags)
+		assert.NotEqual(t, parent.SpanID, child.SpanID)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		_, ok := TraceParentFromContext(context.Background())
+		assert.False(t, ok)
+
+		parent := NewTraceParent()
+		traceParent, ok := TraceParentFromContext(ContextWithTraceParent(context.Background(), parent))
+		assert.True(t, ok)
+		assert.Equal(t, parent, traceParent)
+	})
+}
+
+// ExampleContextWithTraceParent example using ContextWithTraceParent()
+func ExampleContextWithTraceParent() {
+	// IE: the traceparent header of the incoming request
+	traceParent, err := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	client := NewClient(nil, nil, nil)
+	mock := &mockHTTPTraceParent{}
+	client.SetHTTPClient(mock)
+	if _, err = client.GetUserIdentity(
+		ContextWithTraceParent(context.Background(), traceParent), "access-token",
+	); err != nil {
+		fmt.Printf("error occurred: %s", err.Error())
+		return
+	}
+
+	fmt.Printf("traceparent: %s", mock.headers[0])
+	// Output:traceparent: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+}
+
+// BenchmarkParseTraceParent benchmarks the method ParseTraceParent()
+func BenchmarkParseTraceParent(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = ParseTraceParent(testTraceParent)
+	}
+}
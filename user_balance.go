@@ -0,0 +1,68 @@
+package moneybutton
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+/*
+{
+  "data": {
+    "type": "balances",
+    "id": "123",
+    "attributes": {
+      "amount": 12.34,
+      "currency": "USD",
+      "satoshis": 7451000
+    }
+  }
+}
+*/
+
+// GetBalance returns the balance for the specified user (requires PermissionsBalance)
+//
+// Specs: https://docs.moneybutton.com/docs/api-rest-user-balance.html
+func (c *Client) GetBalance(ctx context.Context, userID, accessToken string) (*UserBalance, error) {
+
+	// Check required parameters
+	if len(accessToken) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "accessToken")
+	} else if len(userID) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "userID")
+	}
+
+	// Fire the request
+	response := httpRequest(
+		ctx,
+		c,
+		&httpPayload{
+			Endpoint:       "GetBalance",
+			ExpectedStatus: http.StatusOK,
+			Method:         http.MethodGet,
+			Token:          accessToken,
+			URL:            c.apiURL(fmt.Sprintf(endpointUserBalance, url.PathEscape(userID))),
+		},
+	)
+
+	// Error in request?
+	if response.Error != nil {
+		return nil, response.Error
+	}
+
+	// Create the response
+	doc, data, err := decodeSingle[Balance](response.BodyContents)
+	if err != nil {
+		return nil, err
+	}
+	return &UserBalance{Data: data, JSONAPI: doc.JSONAPI, Links: doc.Links, Meta: doc.Meta}, nil
+}
+
+// Balance will return the balance attributes (nil if the response has no data)
+func (u *UserBalance) Balance() *Balance {
+	if u == nil || u.Data == nil {
+		return nil
+	}
+	return u.Data.Attributes
+}
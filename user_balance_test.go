@@ -0,0 +1,157 @@
+package moneybutton
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHTTPGetUserBalance for mocking requests
+type mockHTTPGetUserBalance struct{}
+
+// Do is a mock http request
+func (m *mockHTTPGetUserBalance) Do(req *http.Request) (*http.Response, error) {
+	resp := new(http.Response)
+	resp.StatusCode = http.StatusBadRequest
+
+	// No req found
+	if req == nil {
+		return resp, fmt.Errorf("missing request")
+	}
+
+	if req.URL.String() == APIURL+fmt.Sprintf(endpointUserBalance, "123") {
+		resp.StatusCode = http.StatusOK
+		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"data":{"type":"balances","id":"123","attributes":{"amount":12.34,"currency":"USD","satoshis":7451000}}}`)))
+	}
+
+	// Default is valid
+	return resp, nil
+}
+
+func TestClient_GetBalance(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing all parameters", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetUserBalance{})
+		assert.NotNil(t, client)
+		balance, err := client.GetBalance(
+			context.Background(),
+			"",
+			"",
+		)
+		assert.Error(t, err)
+		assert.Nil(t, balance)
+	})
+
+	t.Run("missing user id", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetUserBalance{})
+		assert.NotNil(t, client)
+		balance, err := client.GetBalance(
+			context.Background(),
+			"",
+			"1234567",
+		)
+		assert.Error(t, err)
+		assert.Nil(t, balance)
+	})
+
+	t.Run("missing access token", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetUserBalance{})
+		assert.NotNil(t, client)
+		balance, err := client.GetBalance(
+			context.Background(),
+			"123",
+			"",
+		)
+		assert.Error(t, err)
+		assert.Nil(t, balance)
+	})
+
+	t.Run("api error response", func(t *testing.T) {
+		client := newTestClient(&mockHTTPAPIError{})
+		assert.NotNil(t, client)
+		balance, err := client.GetBalance(
+			context.Background(),
+			"123",
+			"1234567",
+		)
+		assert.Error(t, err)
+		assert.Nil(t, balance)
+	})
+
+	t.Run("http error", func(t *testing.T) {
+		client := newTestClient(&mockHTTPError{})
+		assert.NotNil(t, client)
+		balance, err := client.GetBalance(
+			context.Background(),
+			"123",
+			"1234567",
+		)
+		assert.Error(t, err)
+		assert.Nil(t, balance)
+	})
+
+	t.Run("valid response", func(t *testing.T) {
+		client := newTestClient(&mockHTTPGetUserBalance{})
+		assert.NotNil(t, client)
+		balance, err := client.GetBalance(
+			context.Background(),
+			"123",
+			"1234567",
+		)
+		assert.NoError(t, err)
+		assert.NotNil(t, balance)
+		assert.Equal(t, "balances", balance.Data.Type)
+		assert.Equal(t, "123", balance.Data.ID)
+		assert.Equal(t, "12.34", balance.Data.Attributes.Amount.String())
+		assert.Equal(t, "USD", balance.Data.Attributes.Currency)
+		assert.Equal(t, "7451000", balance.Data.Attributes.Satoshis.String())
+		assert.Equal(t, balance.Data.Attributes, balance.Balance())
+	})
+
+	t.Run("satoshis as a string", func(t *testing.T) {
+		client := newTestClient(&mockHTTPErrorResponse{
+			status: http.StatusOK,
+			body:   `{"data":{"type":"balances","id":"123","attributes":{"amount":"12.34","currency":"USD","satoshis":"7451000"}}}`,
+		})
+		balance, err := client.GetBalance(
+			context.Background(),
+			"123",
+			"1234567",
+		)
+		require.NoError(t, err)
+		satoshis, err := balance.Balance().Satoshis.Int64()
+		require.NoError(t, err)
+		assert.Equal(t, int64(7451000), satoshis)
+		assert.Equal(t, "12.34", balance.Balance().Amount.String())
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		client := newTestClient(&mockHTTPErrorResponse{status: http.StatusOK, body: `{"data":`})
+		balance, err := client.GetBalance(
+			context.Background(),
+			"123",
+			"1234567",
+		)
+		assert.Error(t, err)
+		assert.Nil(t, balance)
+	})
+}
+
+// TestUserBalance_Balance tests the method Balance()
+func TestUserBalance_Balance(t *testing.T) {
+	t.Parallel()
+
+	var balance *UserBalance
+	assert.Nil(t, balance.Balance())
+	assert.Nil(t, (&UserBalance{}).Balance())
+
+	attributes := &Balance{Amount: "12.34", Currency: "USD", Satoshis: "7451000"}
+	assert.Equal(t, attributes, (&UserBalance{Data: &ResourceObject[Balance]{Attributes: attributes}}).Balance())
+}
@@ -2,7 +2,6 @@ package moneybutton
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 )
@@ -38,10 +37,11 @@ func (c *Client) GetUserIdentity(ctx context.Context, accessToken string) (*User
 		ctx,
 		c,
 		&httpPayload{
+			Endpoint:       "GetUserIdentity",
 			ExpectedStatus: http.StatusOK,
 			Method:         http.MethodGet,
 			Token:          accessToken,
-			URL:            endpointUserIdentity,
+			URL:            c.apiURL(endpointUserIdentity),
 		},
 	)
 
@@ -51,9 +51,25 @@ func (c *Client) GetUserIdentity(ctx context.Context, accessToken string) (*User
 	}
 
 	// Create the response
-	identity := new(UserIdentity)
-	if err := json.Unmarshal(response.BodyContents, &identity); err != nil {
+	doc, data, err := decodeSingle[Identity](response.BodyContents)
+	if err != nil {
 		return nil, err
 	}
-	return identity, nil
+	return &UserIdentity{Data: data, JSONAPI: doc.JSONAPI, Links: doc.Links, Meta: doc.Meta}, nil
+}
+
+// Identity will return the identity (nil if the response has no attributes)
+func (u *UserIdentity) Identity() *Identity {
+	if u == nil || u.Data == nil {
+		return nil
+	}
+	return u.Data.Attributes
+}
+
+// UserID will return the ID of the user (empty if the response has no data)
+func (u *UserIdentity) UserID() string {
+	if u == nil || u.Data == nil {
+		return ""
+	}
+	return u.Data.ID
 }
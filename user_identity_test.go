@@ -24,7 +24,7 @@ func (m *mockHTTPGetUserIdentity) Do(req *http.Request) (*http.Response, error)
 		return resp, fmt.Errorf("missing request")
 	}
 
-	if req.URL.String() == endpointUserIdentity {
+	if req.URL.String() == APIURL+endpointUserIdentity {
 		resp.StatusCode = http.StatusOK
 		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"data":{"id":"123","type":"user_identities","attributes":{"id":"123","name":"MrZ"}},"jsonapi":{"version":"1.0"}}`)))
 	}
@@ -83,5 +83,20 @@ func TestClient_GetUserIdentity(t *testing.T) {
 		assert.Equal(t, "123", identity.Data.ID)
 		assert.Equal(t, "123", identity.Data.Attributes.ID)
 		assert.Equal(t, "MrZ", identity.Data.Attributes.Name)
+		assert.Equal(t, "123", identity.UserID())
+		assert.Equal(t, &Identity{ID: "123", Name: "MrZ"}, identity.Identity())
 	})
 }
+
+// TestUserIdentity_Identity tests the methods Identity() and UserID()
+func TestUserIdentity_Identity(t *testing.T) {
+	t.Parallel()
+
+	var identity *UserIdentity
+	assert.Nil(t, identity.Identity())
+	assert.Equal(t, "", identity.UserID())
+
+	identity = &UserIdentity{}
+	assert.Nil(t, identity.Identity())
+	assert.Equal(t, "", identity.UserID())
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 )
 
 /*
@@ -42,10 +44,11 @@ func (c *Client) GetProfile(ctx context.Context, userID, accessToken string) (*U
 		ctx,
 		c,
 		&httpPayload{
+			Endpoint:       "GetProfile",
 			ExpectedStatus: http.StatusOK,
 			Method:         http.MethodGet,
 			Token:          accessToken,
-			URL:            fmt.Sprintf(endpointUserProfile, userID),
+			URL:            c.apiURL(fmt.Sprintf(endpointUserProfile, url.PathEscape(userID))),
 		},
 	)
 
@@ -55,9 +58,91 @@ func (c *Client) GetProfile(ctx context.Context, userID, accessToken string) (*U
 	}
 
 	// Create the response
-	profile := new(UserProfile)
-	if err := json.Unmarshal(response.BodyContents, &profile); err != nil {
+	doc, data, err := decodeSingle[Profile](response.BodyContents)
+	if err != nil {
 		return nil, err
 	}
-	return profile, nil
+	return &UserProfile{Data: data, JSONAPI: doc.JSONAPI, Links: doc.Links, Meta: doc.Meta}, nil
+}
+
+// profileJSON is the wire format of a profile (all fields are strings)
+type profileJSON struct {
+	AvatarURL       string  `json:"avatar-url"`
+	Bio             string  `json:"bio"`
+	CreatedAt       string  `json:"created-at"`
+	DefaultCurrency string  `json:"default-currency"`
+	DefaultLanguage string  `json:"default-language"`
+	Name            string  `json:"name"`
+	PrimaryPaymail  Paymail `json:"primary-paymail"`
+}
+
+// UnmarshalJSON will decode the profile and parse the avatar URL and creation date
+//
+// An invalid avatar URL or creation date is kept as-is in RawAvatarURL or RawCreatedAt
+// (one bad value must not fail the whole response)
+func (p *Profile) UnmarshalJSON(data []byte) error {
+	raw := new(profileJSON)
+	if err := json.Unmarshal(data, raw); err != nil {
+		return err
+	}
+
+	profile := Profile{
+		Bio:             raw.Bio,
+		DefaultCurrency: raw.DefaultCurrency,
+		DefaultLanguage: raw.DefaultLanguage,
+		Name:            raw.Name,
+		PrimaryPaymail:  raw.PrimaryPaymail,
+	}
+	if len(raw.AvatarURL) > 0 {
+		if avatarURL, err := url.Parse(raw.AvatarURL); err != nil {
+			profile.RawAvatarURL = raw.AvatarURL
+		} else {
+			profile.AvatarURL = avatarURL
+		}
+	}
+	if len(raw.CreatedAt) > 0 {
+		if createdAt, err := time.Parse(time.RFC3339, raw.CreatedAt); err != nil {
+			profile.RawCreatedAt = raw.CreatedAt
+		} else {
+			profile.CreatedAt = createdAt
+		}
+	}
+	*p = profile
+	return nil
+}
+
+// MarshalJSON will encode the profile in the same format as the API (raw values are kept)
+func (p Profile) MarshalJSON() ([]byte, error) {
+	raw := profileJSON{
+		AvatarURL:       p.RawAvatarURL,
+		Bio:             p.Bio,
+		CreatedAt:       p.RawCreatedAt,
+		DefaultCurrency: p.DefaultCurrency,
+		DefaultLanguage: p.DefaultLanguage,
+		Name:            p.Name,
+		PrimaryPaymail:  p.PrimaryPaymail,
+	}
+	if p.AvatarURL != nil {
+		raw.AvatarURL = p.AvatarURL.String()
+	}
+	if !p.CreatedAt.IsZero() {
+		raw.CreatedAt = p.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return json.Marshal(raw)
+}
+
+// Profile will return the profile (nil if the response has no attributes)
+func (u *UserProfile) Profile() *Profile {
+	if u == nil || u.Data == nil {
+		return nil
+	}
+	return u.Data.Attributes
+}
+
+// UserID will return the ID of the user (empty if the response has no data)
+func (u *UserProfile) UserID() string {
+	if u == nil || u.Data == nil {
+		return ""
+	}
+	return u.Data.ID
 }
@@ -3,12 +3,16 @@ package moneybutton
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // mockHTTPGetUserProfile for mocking requests
@@ -24,7 +28,7 @@ func (m *mockHTTPGetUserProfile) Do(req *http.Request) (*http.Response, error) {
 		return resp, fmt.Errorf("missing request")
 	}
 
-	if req.URL.String() == fmt.Sprintf(endpointUserProfile, "123") {
+	if req.URL.String() == APIURL+fmt.Sprintf(endpointUserProfile, "123") {
 		resp.StatusCode = http.StatusOK
 		resp.Body = ioutil.NopCloser(bytes.NewBuffer([]byte(`{"data":{"type":"profiles","id":"123","attributes":{"created-at":"2019-03-26T17:33:42.788Z","name":"MrZ","default-currency":"USD","default-language":"en","bio":"I like Money Button.","primary-paymail":"mrz@moneybutton.com","avatar-url":"https://www.gravatar.com/avatar/372bc0ab9b8a8930d4a86b2c5b11f11e?d=identicon"}}}`)))
 	}
@@ -108,12 +112,102 @@ func TestClient_GetProfile(t *testing.T) {
 		assert.NotNil(t, profile)
 		assert.Equal(t, "profiles", profile.Data.Type)
 		assert.Equal(t, "123", profile.Data.ID)
-		assert.Equal(t, "MrZ", profile.Data.Attributes.Name)
-		assert.Equal(t, "https://www.gravatar.com/avatar/372bc0ab9b8a8930d4a86b2c5b11f11e?d=identicon", profile.Data.Attributes.AvatarURL)
-		assert.Equal(t, "I like Money Button.", profile.Data.Attributes.Bio)
-		assert.Equal(t, "2019-03-26T17:33:42.788Z", profile.Data.Attributes.CreatedAt)
-		assert.Equal(t, "USD", profile.Data.Attributes.DefaultCurrency)
-		assert.Equal(t, "en", profile.Data.Attributes.DefaultLanguage)
-		assert.Equal(t, "mrz@moneybutton.com", profile.Data.Attributes.PrimaryPaymail)
+		assert.Equal(t, "123", profile.UserID())
+
+		attributes := profile.Profile()
+		require.NotNil(t, attributes)
+		assert.Equal(t, "MrZ", attributes.Name)
+		require.NotNil(t, attributes.AvatarURL)
+		assert.Equal(t, "https://www.gravatar.com/avatar/372bc0ab9b8a8930d4a86b2c5b11f11e?d=identicon", attributes.AvatarURL.String())
+		assert.Equal(t, "www.gravatar.com", attributes.AvatarURL.Host)
+		assert.Equal(t, "I like Money Button.", attributes.Bio)
+		assert.Equal(t, time.Date(2019, 3, 26, 17, 33, 42, 788000000, time.UTC), attributes.CreatedAt)
+		assert.Equal(t, "USD", attributes.DefaultCurrency)
+		assert.Equal(t, "en", attributes.DefaultLanguage)
+		assert.Equal(t, Paymail("mrz@moneybutton.com"), attributes.PrimaryPaymail)
+		assert.Equal(t, "moneybutton.com", attributes.PrimaryPaymail.Domain())
+	})
+}
+
+// TestUserProfile_Profile tests the methods Profile() and UserID()
+func TestUserProfile_Profile(t *testing.T) {
+	t.Parallel()
+
+	var profile *UserProfile
+	assert.Nil(t, profile.Profile())
+	assert.Equal(t, "", profile.UserID())
+
+	profile = &UserProfile{}
+	assert.Nil(t, profile.Profile())
+	assert.Equal(t, "", profile.UserID())
+}
+
+// TestProfile_UnmarshalJSON tests the method UnmarshalJSON()
+func TestProfile_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty fields", func(t *testing.T) {
+		profile := new(Profile)
+		err := json.Unmarshal([]byte(`{"name":"MrZ","avatar-url":null,"created-at":"","primary-paymail":null}`), profile)
+		require.NoError(t, err)
+		assert.Equal(t, "MrZ", profile.Name)
+		assert.Nil(t, profile.AvatarURL)
+		assert.True(t, profile.CreatedAt.IsZero())
+		assert.Equal(t, Paymail(""), profile.PrimaryPaymail)
+	})
+
+	t.Run("invalid fields", func(t *testing.T) {
+		assert.Error(t, json.Unmarshal([]byte(`{"name":1}`), new(Profile)))
+	})
+
+	t.Run("invalid avatar url is kept", func(t *testing.T) {
+		profile := new(Profile)
+		require.NoError(t, json.Unmarshal([]byte(`{"name":"MrZ","avatar-url":"http://[::1"}`), profile))
+		assert.Equal(t, "MrZ", profile.Name)
+		assert.Nil(t, profile.AvatarURL)
+		assert.Equal(t, "http://[::1", profile.RawAvatarURL)
+
+		data, err := json.Marshal(profile)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"avatar-url":"http://[::1"`)
+	})
+
+	t.Run("invalid created at is kept", func(t *testing.T) {
+		profile := new(Profile)
+		require.NoError(t, json.Unmarshal([]byte(`{"name":"MrZ","created-at":"yesterday"}`), profile))
+		assert.Equal(t, "MrZ", profile.Name)
+		assert.True(t, profile.CreatedAt.IsZero())
+		assert.Equal(t, "yesterday", profile.RawCreatedAt)
+
+		data, err := json.Marshal(profile)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"created-at":"yesterday"`)
+	})
+
+	t.Run("invalid paymail is kept", func(t *testing.T) {
+		profile := new(Profile)
+		require.NoError(t, json.Unmarshal([]byte(`{"name":"MrZ","primary-paymail":"not-a-paymail"}`), profile))
+		assert.Equal(t, "MrZ", profile.Name)
+		assert.Equal(t, Paymail("not-a-paymail"), profile.PrimaryPaymail)
+		assert.False(t, profile.PrimaryPaymail.Valid())
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		avatarURL, err := url.Parse("https://www.gravatar.com/avatar/123")
+		require.NoError(t, err)
+		profile := Profile{
+			AvatarURL:      avatarURL,
+			CreatedAt:      time.Date(2019, 3, 26, 17, 33, 42, 788000000, time.UTC),
+			Name:           "MrZ",
+			PrimaryPaymail: "mrz@moneybutton.com",
+		}
+
+		data, err := json.Marshal(profile)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"created-at":"2019-03-26T17:33:42.788Z"`)
+
+		decoded := new(Profile)
+		require.NoError(t, json.Unmarshal(data, decoded))
+		assert.Equal(t, profile, *decoded)
 	})
 }
@@ -0,0 +1,195 @@
+package moneybutton
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Webhook errors (the handler responds with an error status and the callback is not called)
+var (
+	// ErrInvalidWebhookSecret is returned when the webhook secret does not match
+	ErrInvalidWebhookSecret = errors.New("invalid webhook secret")
+
+	// ErrWebhookBodyTooLarge is returned when the webhook body is larger than the max body size
+	ErrWebhookBodyTooLarge = errors.New("webhook body is too large")
+)
+
+/*
+{
+  "secret": "the-webhook-secret",
+  "payment": {
+    "id": "1040",
+    "buttonId": "order-123",
+    "buttonData": "{\"order\":\"123\"}",
+    "status": "COMPLETED",
+    "txid": "t1234",
+    "normalizedTxid": "n1234",
+    "amount": "0.01",
+    "currency": "USD",
+    "satoshis": "4210",
+    "userId": "456",
+    "createdAt": "2019-03-26T17:33:42.788Z",
+    "updatedAt": "2019-03-26T17:33:45.123Z"
+  }
+}
+*/
+
+// WebhookPayment is the payment sent in a webhook notification
+//
+// Specs: https://docs.moneybutton.com/docs/api-webhooks.html
+type WebhookPayment struct {
+	Amount            json.Number   `json:"amount"`            // Amount in the currency (IE: 0.01)
+	ButtonData        string        `json:"buttonData"`        // Custom data set on the button
+	ButtonID          string        `json:"buttonId"`          // Custom ID set on the button
+	CreatedAt         time.Time     `json:"createdAt"`         // When the payment was created
+	Currency          string        `json:"currency"`          // IE: USD
+	ID                string        `json:"id"`                // Payment ID
+	NormalizedTxID    string        `json:"normalizedTxid"`    // Normalized transaction ID
+	Satoshis          json.Number   `json:"satoshis"`          // Amount in satoshis
+	Status            PaymentStatus `json:"status"`            // IE: COMPLETED
+	StatusDescription string        `json:"statusDescription"` // Description of the status (if any)
+	TxID              string        `json:"txid"`              // Transaction ID
+	UpdatedAt         time.Time     `json:"updatedAt"`         // When the payment was last updated
+	UserID            string        `json:"userId"`            // The user that made the payment
+}
+
+// webhookRequest is the body of a webhook notification
+type webhookRequest struct {
+	Payment *WebhookPayment `json:"payment"`
+	Secret  string          `json:"secret"`
+}
+
+// WebhookCallback is called with the payment of a webhook notification
+//
+// Returning an error responds with a 500 (MoneyButton will retry the notification)
+type WebhookCallback func(ctx context.Context, payment *WebhookPayment) error
+
+// WebhookHandler is an http.Handler that receives payment notifications
+//
+// The secret is verified in constant time and the payment is dispatched to the
+// callback registered for its status (see: On and OnAny)
+type WebhookHandler struct {
+	callbacks   map[PaymentStatus]WebhookCallback
+	fallback    WebhookCallback
+	maxBodySize int64
+	mu          sync.RWMutex
+	secret      string
+}
+
+// NewWebhookHandler will return a new webhook handler using the secret set on the button
+func NewWebhookHandler(secret string) (*WebhookHandler, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "secret")
+	}
+	return &WebhookHandler{
+		callbacks:   make(map[PaymentStatus]WebhookCallback),
+		maxBodySize: defaultWebhookMaxBodySize,
+		secret:      secret,
+	}, nil
+}
+
+// On will register the callback for payments with the status (IE: PaymentStatusCompleted)
+//
+// Registering a nil callback will remove the callback for the status
+func (h *WebhookHandler) On(status PaymentStatus, callback WebhookCallback) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if callback == nil {
+		delete(h.callbacks, status)
+		return
+	}
+	h.callbacks[status] = callback
+}
+
+// OnAny will register the callback for payments with a status that has no callback
+func (h *WebhookHandler) OnAny(callback WebhookCallback) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fallback = callback
+}
+
+// SetMaxBodySize will change the max size (in bytes) of a webhook body
+func (h *WebhookHandler) SetMaxBodySize(maxBodySize int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if maxBodySize <= 0 {
+		maxBodySize = defaultWebhookMaxBodySize
+	}
+	h.maxBodySize = maxBodySize
+}
+
+// ServeHTTP will parse and verify the webhook and dispatch the payment to the callback
+//
+// Notifications without a callback for the status are acknowledged (200)
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.mu.RLock()
+	maxBodySize := h.maxBodySize
+	h.mu.RUnlock()
+
+	// Parse and verify the webhook
+	payment, err := h.parse(req.Body, maxBodySize)
+	if errors.Is(err, ErrWebhookBodyTooLarge) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	} else if errors.Is(err, ErrInvalidWebhookSecret) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Dispatch the payment
+	if callback := h.callback(payment.Status); callback != nil {
+		if err = callback(req.Context(), payment); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// parse will read, decode and verify the webhook body
+func (h *WebhookHandler) parse(body io.Reader, maxBodySize int64) (*WebhookPayment, error) {
+	data, err := io.ReadAll(io.LimitReader(body, maxBodySize+1))
+	if err != nil {
+		return nil, err
+	} else if int64(len(data)) > maxBodySize {
+		return nil, ErrWebhookBodyTooLarge
+	}
+
+	webhook := new(webhookRequest)
+	if err = json.Unmarshal(data, webhook); err != nil {
+		return nil, fmt.Errorf("malformed webhook body: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(webhook.Secret), []byte(h.secret)) != 1 {
+		return nil, ErrInvalidWebhookSecret
+	}
+	if webhook.Payment == nil || len(webhook.Payment.ID) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "payment")
+	}
+	return webhook.Payment, nil
+}
+
+// callback will return the callback for the status (or the fallback)
+func (h *WebhookHandler) callback(status PaymentStatus) WebhookCallback {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if callback, ok := h.callbacks[status]; ok {
+		return callback
+	}
+	return h.fallback
+}
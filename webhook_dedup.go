@@ -0,0 +1,216 @@
+package moneybutton
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DedupStore records the webhook deliveries that were processed (keyed by payment ID and status)
+//
+// Claim must be atomic: only one caller can claim a key until it is released or expires
+type DedupStore interface {
+	// Claim will record the key, returns false if the key was already claimed
+	Claim(ctx context.Context, key string) (bool, error)
+
+	// Release will remove the key (the delivery was not processed and can be retried)
+	Release(ctx context.Context, key string) error
+}
+
+// DedupWebhookCallback will wrap the callback so each status of a payment is only processed once
+//
+// Redelivered notifications are acknowledged without calling the callback. If the
+// callback returns an error the claim is released, so the redelivery is processed.
+//
+//	handler.On(moneybutton.PaymentStatusCompleted, moneybutton.DedupWebhookCallback(store, creditUser))
+func DedupWebhookCallback(store DedupStore, callback WebhookCallback) WebhookCallback {
+	return func(ctx context.Context, payment *WebhookPayment) error {
+		key := webhookDedupKey(payment)
+		claimed, err := store.Claim(ctx, key)
+		if err != nil {
+			return err
+		} else if !claimed {
+			return nil
+		}
+
+		if err = callback(ctx, payment); err != nil {
+			if releaseErr := store.Release(ctx, key); releaseErr != nil {
+				return fmt.Errorf("%w (failed to release %s: %s)", err, key, releaseErr.Error())
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// webhookDedupKey will return the dedup key for the payment status transition
+func webhookDedupKey(payment *WebhookPayment) string {
+	return payment.ID + ":" + string(payment.Status)
+}
+
+// MemoryDedupStore is an in-memory DedupStore
+//
+// By default every key is kept for the life of the process (exactly-once). A capacity
+// (least recently used keys are evicted first) or a ttl bounds the memory instead, and
+// a redelivery after its key was evicted or expired is processed again (best-effort)
+type MemoryDedupStore struct {
+	capacity int
+	entries  map[string]*list.Element
+	mu       sync.Mutex
+	now      func() time.Time
+	order    *list.List
+	ttl      time.Duration
+}
+
+// memoryDedupEntry is a claimed key
+type memoryDedupEntry struct {
+	claimedAt time.Time
+	key       string
+}
+
+// NewMemoryDedupStore will create a new in-memory DedupStore
+//
+// Capacity is the max number of keys and ttl is how long a key is kept (0 is no limit).
+// Either limit makes the dedup best-effort, set them above the redelivery window of the webhooks
+func NewMemoryDedupStore(capacity int, ttl time.Duration) *MemoryDedupStore {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &MemoryDedupStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		now:      time.Now,
+		order:    list.New(),
+		ttl:      ttl,
+	}
+}
+
+// Claim will record the key, returns false if the key was already claimed (and has not expired)
+func (m *MemoryDedupStore) Claim(_ context.Context, key string) (bool, error) {
+	if len(key) == 0 {
+		return false, fmt.Errorf("missing required parameter: %s", "key")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if element, ok := m.entries[key]; ok {
+		if !dedupExpired(element.Value.(*memoryDedupEntry).claimedAt, now, m.ttl) {
+			m.order.MoveToFront(element) // Redelivered keys are used (the ttl is still from the claim)
+			return false, nil
+		}
+		m.remove(element)
+	}
+
+	// Evict the least recently used keys (if there is a capacity)
+	for m.capacity > 0 && m.order.Len() >= m.capacity {
+		m.remove(m.order.Back())
+	}
+	m.entries[key] = m.order.PushFront(&memoryDedupEntry{claimedAt: now, key: key})
+	return true, nil
+}
+
+// Release will remove the key
+func (m *MemoryDedupStore) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+	return nil
+}
+
+// Len will return the number of keys in the store (including expired keys not yet removed)
+func (m *MemoryDedupStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// remove will remove the element from the list and the map
+func (m *MemoryDedupStore) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*memoryDedupEntry).key)
+}
+
+// FileDedupStore is a DedupStore that persists all claimed keys to a single JSON file
+//
+// Use this when deliveries must not be processed again after a restart
+type FileDedupStore struct {
+	filename string
+	mu       sync.Mutex
+	now      func() time.Time
+	ttl      time.Duration
+}
+
+// NewFileDedupStore will create a new DedupStore using the given JSON file
+// (expired keys are removed on every write, a ttl of 0 will never expire keys)
+func NewFileDedupStore(filename string, ttl time.Duration) (*FileDedupStore, error) {
+	if len(filename) == 0 {
+		return nil, fmt.Errorf("missing required parameter: %s", "filename")
+	}
+	return &FileDedupStore{filename: filename, now: time.Now, ttl: ttl}, nil
+}
+
+// Claim will record the key, returns false if the key was already claimed (and has not expired)
+func (f *FileDedupStore) Claim(_ context.Context, key string) (bool, error) {
+	if len(key) == 0 {
+		return false, fmt.Errorf("missing required parameter: %s", "key")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys, err := f.load()
+	if err != nil {
+		return false, err
+	}
+	now := f.now()
+	if claimedAt, ok := keys[key]; ok && !dedupExpired(claimedAt, now, f.ttl) {
+		return false, nil
+	}
+
+	// Remove expired keys
+	for existing, claimedAt := range keys {
+		if dedupExpired(claimedAt, now, f.ttl) {
+			delete(keys, existing)
+		}
+	}
+	keys[key] = now
+	if err = writeJSONFileAtomic(f.filename, keys); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Release will remove the key
+func (f *FileDedupStore) Release(_ context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys, err := f.load()
+	if err != nil {
+		return err
+	} else if _, ok := keys[key]; !ok {
+		return nil
+	}
+	delete(keys, key)
+	return writeJSONFileAtomic(f.filename, keys)
+}
+
+// load will read all the claimed keys from the file
+func (f *FileDedupStore) load() (map[string]time.Time, error) {
+	keys := make(map[string]time.Time)
+	if err := readJSONFile(f.filename, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// dedupExpired will return true if the key claimed at the time has expired
+func dedupExpired(claimedAt, now time.Time, ttl time.Duration) bool {
+	return ttl > 0 && !now.Before(claimedAt.Add(ttl))
+}
@@ -0,0 +1,277 @@
+package moneybutton
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// errorDedupStore is a DedupStore that always fails
+type errorDedupStore struct{}
+
+// Claim will return an error
+func (e *errorDedupStore) Claim(_ context.Context, _ string) (bool, error) {
+	return false, fmt.Errorf("store is down")
+}
+
+// Release will return an error
+func (e *errorDedupStore) Release(_ context.Context, _ string) error {
+	return fmt.Errorf("store is down")
+}
+
+// testDedupStore runs the common DedupStore tests
+func testDedupStore(t *testing.T, store DedupStore) {
+	ctx := context.Background()
+
+	_, err := store.Claim(ctx, "")
+	assert.Error(t, err)
+
+	claimed, err := store.Claim(ctx, "1040:COMPLETED")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = store.Claim(ctx, "1040:COMPLETED")
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	// Another status of the same payment
+	claimed, err = store.Claim(ctx, "1040:RECEIVED")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// Released keys can be claimed again
+	require.NoError(t, store.Release(ctx, "1040:COMPLETED"))
+	require.NoError(t, store.Release(ctx, "unknown"))
+	claimed, err = store.Claim(ctx, "1040:COMPLETED")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+}
+
+// TestMemoryDedupStore tests the MemoryDedupStore
+func TestMemoryDedupStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("claim and release", func(t *testing.T) {
+		testDedupStore(t, NewMemoryDedupStore(0, 0))
+	})
+
+	t.Run("unbounded by default", func(t *testing.T) {
+		ctx := context.Background()
+		store := NewMemoryDedupStore(-1, 0)
+		assert.Equal(t, 0, store.capacity)
+		for i := 0; i < 20000; i++ {
+			claimed, err := store.Claim(ctx, fmt.Sprintf("%d:COMPLETED", i))
+			require.NoError(t, err)
+			require.True(t, claimed)
+		}
+
+		// No key was evicted
+		claimed, err := store.Claim(ctx, "0:COMPLETED")
+		require.NoError(t, err)
+		assert.False(t, claimed)
+		assert.Equal(t, 20000, store.Len())
+	})
+
+	t.Run("least recently used is evicted", func(t *testing.T) {
+		ctx := context.Background()
+		store := NewMemoryDedupStore(2, 0)
+		for _, key := range []string{"a", "b", "c"} {
+			claimed, err := store.Claim(ctx, key)
+			require.NoError(t, err)
+			assert.True(t, claimed)
+		}
+		assert.Equal(t, 2, store.Len())
+
+		claimed, err := store.Claim(ctx, "c")
+		require.NoError(t, err)
+		assert.False(t, claimed)
+
+		// "a" was evicted
+		claimed, err = store.Claim(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, claimed)
+		assert.Equal(t, 2, store.Len())
+	})
+
+	t.Run("redelivered key is not evicted", func(t *testing.T) {
+		ctx := context.Background()
+		store := NewMemoryDedupStore(2, 0)
+		for _, key := range []string{"a", "b", "a", "c"} {
+			_, err := store.Claim(ctx, key)
+			require.NoError(t, err)
+		}
+
+		// "a" was used after "b" so "b" was evicted
+		claimed, err := store.Claim(ctx, "a")
+		require.NoError(t, err)
+		assert.False(t, claimed)
+
+		claimed, err = store.Claim(ctx, "b")
+		require.NoError(t, err)
+		assert.True(t, claimed)
+	})
+
+	t.Run("keys expire", func(t *testing.T) {
+		ctx := context.Background()
+		now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+		store := NewMemoryDedupStore(10, time.Hour)
+		store.now = func() time.Time { return now }
+
+		claimed, err := store.Claim(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		now = now.Add(59 * time.Minute)
+		claimed, err = store.Claim(ctx, "a")
+		require.NoError(t, err)
+		assert.False(t, claimed)
+
+		now = now.Add(time.Minute)
+		claimed, err = store.Claim(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, claimed)
+		assert.Equal(t, 1, store.Len())
+	})
+
+	t.Run("concurrent claims", func(t *testing.T) {
+		store := NewMemoryDedupStore(0, 0)
+		var wg sync.WaitGroup
+		var count int32
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if claimed, _ := store.Claim(context.Background(), "a"); claimed {
+					atomic.AddInt32(&count, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), count)
+	})
+}
+
+// TestFileDedupStore tests the FileDedupStore
+func TestFileDedupStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing filename", func(t *testing.T) {
+		store, err := NewFileDedupStore("", 0)
+		assert.Error(t, err)
+		assert.Nil(t, store)
+	})
+
+	t.Run("claim and release", func(t *testing.T) {
+		store, err := NewFileDedupStore(filepath.Join(t.TempDir(), "dedup.json"), 0)
+		require.NoError(t, err)
+		testDedupStore(t, store)
+	})
+
+	t.Run("persists between stores", func(t *testing.T) {
+		ctx := context.Background()
+		filename := filepath.Join(t.TempDir(), "dedup.json")
+		store, err := NewFileDedupStore(filename, 0)
+		require.NoError(t, err)
+		claimed, err := store.Claim(ctx, "a")
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		var reopened *FileDedupStore
+		reopened, err = NewFileDedupStore(filename, 0)
+		require.NoError(t, err)
+		claimed, err = reopened.Claim(ctx, "a")
+		require.NoError(t, err)
+		assert.False(t, claimed)
+	})
+
+	t.Run("expired keys are removed", func(t *testing.T) {
+		ctx := context.Background()
+		now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+		store, err := NewFileDedupStore(filepath.Join(t.TempDir(), "dedup.json"), time.Hour)
+		require.NoError(t, err)
+		store.now = func() time.Time { return now }
+
+		_, err = store.Claim(ctx, "a")
+		require.NoError(t, err)
+
+		now = now.Add(time.Hour)
+		claimed, err := store.Claim(ctx, "b")
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		keys, err := store.load()
+		require.NoError(t, err)
+		assert.Len(t, keys, 1)
+		assert.Contains(t, keys, "b")
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "dedup.json")
+		require.NoError(t, ioutil.WriteFile(filename, []byte(`{`), 0o600))
+		store, err := NewFileDedupStore(filename, 0)
+		require.NoError(t, err)
+
+		_, err = store.Claim(context.Background(), "a")
+		assert.Error(t, err)
+		assert.Error(t, store.Release(context.Background(), "a"))
+	})
+}
+
+// TestDedupWebhookCallback tests the method DedupWebhookCallback()
+func TestDedupWebhookCallback(t *testing.T) {
+	t.Parallel()
+
+	t.Run("redelivered notifications are skipped", func(t *testing.T) {
+		handler, err := NewWebhookHandler(testWebhookSecret)
+		require.NoError(t, err)
+
+		var credited []PaymentStatus
+		handler.OnAny(DedupWebhookCallback(NewMemoryDedupStore(0, 0), func(_ context.Context, payment *WebhookPayment) error {
+			credited = append(credited, payment.Status)
+			return nil
+		}))
+
+		for _, status := range []string{"RECEIVED", "COMPLETED", "COMPLETED", "RECEIVED"} {
+			recorder := serveWebhook(handler, http.MethodPost, testWebhookBody(testWebhookSecret, status))
+			assert.Equal(t, http.StatusOK, recorder.Code)
+		}
+		assert.Equal(t, []PaymentStatus{PaymentStatusReceived, PaymentStatusCompleted}, credited)
+	})
+
+	t.Run("failed callback is retried", func(t *testing.T) {
+		store := NewMemoryDedupStore(0, 0)
+		calls := 0
+		callback := DedupWebhookCallback(store, func(_ context.Context, _ *WebhookPayment) error {
+			calls++
+			if calls == 1 {
+				return fmt.Errorf("database is down")
+			}
+			return nil
+		})
+
+		payment := &WebhookPayment{ID: "1040", Status: PaymentStatusCompleted}
+		assert.Error(t, callback(context.Background(), payment))
+		assert.NoError(t, callback(context.Background(), payment))
+		assert.NoError(t, callback(context.Background(), payment))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		called := false
+		callback := DedupWebhookCallback(&errorDedupStore{}, func(_ context.Context, _ *WebhookPayment) error {
+			called = true
+			return nil
+		})
+		assert.Error(t, callback(context.Background(), &WebhookPayment{ID: "1040", Status: PaymentStatusCompleted}))
+		assert.False(t, called)
+	})
+}
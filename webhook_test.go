@@ -0,0 +1,229 @@
+package moneybutton
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testWebhookSecret is the secret used for webhook tests
+const testWebhookSecret = "the-webhook-secret"
+
+// testWebhookBody will return a webhook body for the secret and status
+func testWebhookBody(secret, status string) string {
+	return fmt.Sprintf(`{"secret":"%s","payment":{"id":"1040","buttonId":"order-123","buttonData":"{\"order\":\"123\"}","status":"%s","txid":"t1234","normalizedTxid":"n1234","amount":"0.01","currency":"USD","satoshis":"4210","userId":"456","createdAt":"2019-03-26T17:33:42.788Z","updatedAt":"2019-03-26T17:33:45.123Z"}}`,
+		secret, status)
+}
+
+// serveWebhook will send the body to the handler and return the response
+func serveWebhook(handler http.Handler, method, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, "/webhook", strings.NewReader(body)))
+	return recorder
+}
+
+// TestNewWebhookHandler tests the method NewWebhookHandler()
+func TestNewWebhookHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("missing secret", func(t *testing.T) {
+		handler, err := NewWebhookHandler("")
+		assert.Error(t, err)
+		assert.Nil(t, handler)
+	})
+
+	t.Run("valid handler", func(t *testing.T) {
+		handler, err := NewWebhookHandler(testWebhookSecret)
+		require.NoError(t, err)
+		require.NotNil(t, handler)
+		assert.Equal(t, int64(defaultWebhookMaxBodySize), handler.maxBodySize)
+	})
+}
+
+// TestWebhookHandler_ServeHTTP tests the method ServeHTTP()
+func TestWebhookHandler_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	t.Run("dispatches by status", func(t *testing.T) {
+		handler, err := NewWebhookHandler(testWebhookSecret)
+		require.NoError(t, err)
+
+		var completed, received []*WebhookPayment
+		handler.On(PaymentStatusCompleted, func(_ context.Context, payment *WebhookPayment) error {
+			completed = append(completed, payment)
+			return nil
+		})
+		handler.On(PaymentStatusReceived, func(_ context.Context, payment *WebhookPayment) error {
+			received = append(received, payment)
+			return nil
+		})
+
+		recorder := serveWebhook(handler, http.MethodPost, testWebhookBody(testWebhookSecret, "COMPLETED"))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		require.Len(t, completed, 1)
+		assert.Len(t, received, 0)
+
+		payment := completed[0]
+		assert.Equal(t, "1040", payment.ID)
+		assert.Equal(t, "order-123", payment.ButtonID)
+		assert.Equal(t, `{"order":"123"}`, payment.ButtonData)
+		assert.Equal(t, PaymentStatusCompleted, payment.Status)
+		assert.Equal(t, "t1234", payment.TxID)
+		assert.Equal(t, "n1234", payment.NormalizedTxID)
+		assert.Equal(t, "0.01", payment.Amount.String())
+		assert.Equal(t, "USD", payment.Currency)
+		assert.Equal(t, "4210", payment.Satoshis.String())
+		assert.Equal(t, "456", payment.UserID)
+		assert.Equal(t, time.Date(2019, 3, 26, 17, 33, 42, 788000000, time.UTC), payment.CreatedAt)
+
+		recorder = serveWebhook(handler, http.MethodPost, testWebhookBody(testWebhookSecret, "RECEIVED"))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Len(t, received, 1)
+	})
+
+	t.Run("status without a callback is acknowledged", func(t *testing.T) {
+		handler, err := NewWebhookHandler(testWebhookSecret)
+		require.NoError(t, err)
+
+		called := false
+		handler.On(PaymentStatusCompleted, func(_ context.Context, _ *WebhookPayment) error {
+			called = true
+			return nil
+		})
+		handler.On(PaymentStatusCompleted, nil)
+
+		recorder := serveWebhook(handler, http.MethodPost, testWebhookBody(testWebhookSecret, "COMPLETED"))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.False(t, called)
+	})
+
+	t.Run("fallback callback", func(t *testing.T) {
+		handler, err := NewWebhookHandler(testWebhookSecret)
+		require.NoError(t, err)
+
+		var statuses []PaymentStatus
+		handler.OnAny(func(_ context.Context, payment *WebhookPayment) error {
+			statuses = append(statuses, payment.Status)
+			return nil
+		})
+		handler.On(PaymentStatusCompleted, func(_ context.Context, _ *WebhookPayment) error {
+			return nil
+		})
+
+		serveWebhook(handler, http.MethodPost, testWebhookBody(testWebhookSecret, "COMPLETED"))
+		serveWebhook(handler, http.MethodPost, testWebhookBody(testWebhookSecret, "FAILED"))
+		assert.Equal(t, []PaymentStatus{PaymentStatusFailed}, statuses)
+	})
+
+	t.Run("callback error", func(t *testing.T) {
+		handler, err := NewWebhookHandler(testWebhookSecret)
+		require.NoError(t, err)
+		handler.OnAny(func(_ context.Context, _ *WebhookPayment) error {
+			return fmt.Errorf("database is down")
+		})
+
+		recorder := serveWebhook(handler, http.MethodPost, testWebhookBody(testWebhookSecret, "COMPLETED"))
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		assert.NotContains(t, recorder.Body.String(), "database")
+	})
+
+	t.Run("invalid secret", func(t *testing.T) {
+		handler, err := NewWebhookHandler(testWebhookSecret)
+		require.NoError(t, err)
+		called := false
+		handler.OnAny(func(_ context.Context, _ *WebhookPayment) error {
+			called = true
+			return nil
+		})
+
+		for _, secret := range []string{"", "wrong", testWebhookSecret + "x"} {
+			recorder := serveWebhook(handler, http.MethodPost, testWebhookBody(secret, "COMPLETED"))
+			assert.Equal(t, http.StatusUnauthorized, recorder.Code, secret)
+		}
+		assert.False(t, called)
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		handler, err := NewWebhookHandler(testWebhookSecret)
+		require.NoError(t, err)
+
+		for _, body := range []string{
+			``,
+			`{`,
+			`{"secret":"` + testWebhookSecret + `"}`,
+			`{"secret":"` + testWebhookSecret + `","payment":{"status":"COMPLETED"}}`,
+			`{"secret":"` + testWebhookSecret + `","payment":{"id":"1","amount":"abc"}}`,
+		} {
+			recorder := serveWebhook(handler, http.MethodPost, body)
+			assert.Equal(t, http.StatusBadRequest, recorder.Code, body)
+		}
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		handler, err := NewWebhookHandler(testWebhookSecret)
+		require.NoError(t, err)
+		body := testWebhookBody(testWebhookSecret, "COMPLETED")
+
+		handler.SetMaxBodySize(int64(len(body)))
+		recorder := serveWebhook(handler, http.MethodPost, body)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		handler.SetMaxBodySize(int64(len(body) - 1))
+		recorder = serveWebhook(handler, http.MethodPost, body)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+
+		// Reset to the default
+		handler.SetMaxBodySize(0)
+		assert.Equal(t, int64(defaultWebhookMaxBodySize), handler.maxBodySize)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		handler, err := NewWebhookHandler(testWebhookSecret)
+		require.NoError(t, err)
+
+		recorder := serveWebhook(handler, http.MethodGet, "")
+		assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+		assert.Equal(t, http.MethodPost, recorder.Header().Get("Allow"))
+	})
+
+	t.Run("concurrent notifications", func(t *testing.T) {
+		handler, err := NewWebhookHandler(testWebhookSecret)
+		require.NoError(t, err)
+
+		var mu sync.Mutex
+		count := 0
+		handler.On(PaymentStatusCompleted, func(_ context.Context, _ *WebhookPayment) error {
+			mu.Lock()
+			count++
+			mu.Unlock()
+			return nil
+		})
+
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, postErr := http.Post(server.URL, "application/json",
+					strings.NewReader(testWebhookBody(testWebhookSecret, "COMPLETED")))
+				if assert.NoError(t, postErr) {
+					assert.Equal(t, http.StatusOK, resp.StatusCode)
+					_ = resp.Body.Close()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 10, count)
+	})
+}